@@ -21,10 +21,21 @@ type Achievement struct {
 	Attachments []Attachment         `bson:"attachments,omitempty" json:"attachments,omitempty"`
 	Tags        []string             `bson:"tags,omitempty" json:"tags,omitempty"`
 	Points      int                  `bson:"points,omitempty" json:"points,omitempty"`
+	// rule rulebook yang menghasilkan Points (diisi saat verifikasi)
+	ScoringRuleID string             `bson:"scoringRuleId,omitempty" json:"scoring_rule_id,omitempty"`
 	Status      string               `bson:"status" json:"status"`
 	CreatedAt   time.Time            `bson:"createdAt" json:"created_at"`
 	UpdatedAt   time.Time            `bson:"updatedAt" json:"updated_at"`
 	History     []AchievementHistory `bson:"history,omitempty" json:"history,omitempty"`
+	// permintaan revisi dari dosen, terbaru di akhir
+	RevisionRequests []RevisionRequest `bson:"revisionRequests,omitempty" json:"revision_requests,omitempty"`
+	// approval chain yang dipakai sejak diajukan & jumlah tahap yang sudah menyetujui
+	ApprovalChainID string          `bson:"approvalChainId,omitempty" json:"approval_chain_id,omitempty"`
+	ApprovalStage   int             `bson:"approvalStage,omitempty" json:"approval_stage,omitempty"`
+	Approvals       []StageApproval `bson:"approvals,omitempty" json:"approvals,omitempty"`
+	// salinan chain saat diajukan; perubahan / penghapusan chain tidak memengaruhi pengajuan ini
+	ApprovalChainName string          `bson:"approvalChainName,omitempty" json:"approval_chain_name,omitempty"`
+	ApprovalStages    []ApprovalStage `bson:"approvalStages,omitempty" json:"approval_stages,omitempty"`
 }
 
 //
@@ -112,6 +123,51 @@ type AchievementHistory struct {
 	Notes         string    `bson:"notes,omitempty" json:"notes,omitempty"`
 }
 
+//
+// ====================================================
+// REVISION REQUEST
+// ====================================================
+//
+// Snapshot menyimpan isi prestasi saat komentar dibuat sebagai dasar diff
+// setelah mahasiswa mengajukan ulang.
+type RevisionRequest struct {
+	ID            string              `bson:"id" json:"id"`
+	RequestedBy   string              `bson:"requestedBy" json:"requested_by"`
+	RequestedByID string              `bson:"requestedById" json:"requested_by_id"`
+	RequestedAt   time.Time           `bson:"requestedAt" json:"requested_at"`
+	Comments      []RevisionComment   `bson:"comments" json:"comments"`
+	Snapshot      AchievementSnapshot `bson:"snapshot" json:"-"`
+}
+
+// RevisionComment: Field memakai nama JSON (misal "title", "details.rank"),
+// AttachmentURL diisi jika komentar untuk lampiran tertentu.
+type RevisionComment struct {
+	Field         string `bson:"field" json:"field"`
+	AttachmentURL string `bson:"attachmentUrl,omitempty" json:"attachment_url,omitempty"`
+	Comment       string `bson:"comment" json:"comment"`
+}
+
+type AchievementSnapshot struct {
+	AchievementType string             `bson:"achievementType" json:"achievementType"`
+	Title           string             `bson:"title" json:"title"`
+	Description     string             `bson:"description" json:"description"`
+	Details         AchievementDetails `bson:"details" json:"details"`
+	Attachments     []Attachment       `bson:"attachments,omitempty" json:"attachments,omitempty"`
+	Tags            []string           `bson:"tags,omitempty" json:"tags,omitempty"`
+}
+
+// Snapshot mengambil field yang bisa diubah mahasiswa
+func (a *Achievement) Snapshot() AchievementSnapshot {
+	return AchievementSnapshot{
+		AchievementType: a.AchievementType,
+		Title:           a.Title,
+		Description:     a.Description,
+		Details:         a.Details,
+		Attachments:     a.Attachments,
+		Tags:            a.Tags,
+	}
+}
+
 //
 // ====================================================
 // ACHIEVEMENT REFERENCE (POSTGRESQL)
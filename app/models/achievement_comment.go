@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ====================================================
+// ACHIEVEMENT COMMENT (MONGODB, collection achievement_comments)
+// ====================================================
+type AchievementComment struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	AchievementID  string             `bson:"achievementId" json:"achievement_id"`
+	AuthorID       string             `bson:"authorId" json:"author_id"`
+	AuthorUsername string             `bson:"authorUsername" json:"author_username"`
+	Body           string             `bson:"body" json:"body"`
+	Mentions       []CommentMention   `bson:"mentions,omitempty" json:"mentions,omitempty"`
+	CreatedAt      time.Time          `bson:"createdAt" json:"created_at"`
+	EditedAt       *time.Time         `bson:"editedAt,omitempty" json:"edited_at,omitempty"`
+	DeletedAt      *time.Time         `bson:"deletedAt,omitempty" json:"deleted_at,omitempty"`
+}
+
+type CommentMention struct {
+	UserID   string `bson:"userId" json:"user_id"`
+	Username string `bson:"username" json:"username"`
+}
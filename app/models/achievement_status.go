@@ -0,0 +1,14 @@
+package models
+
+// Status prestasi; transisi antar status didefinisikan di services/achievement_workflow.go
+const (
+	AchievementStatusDraft     = "draft"
+	AchievementStatusSubmitted = "submitted"
+	// dosen meminta perbaikan, mahasiswa boleh mengubah data lalu mengajukan ulang
+	AchievementStatusRevisionRequested = "revision_requested"
+	// sebagian tahap approval chain sudah menyetujui, menunggu tahap berikutnya
+	AchievementStatusPartiallyApproved = "partially_approved"
+	AchievementStatusVerified          = "verified"
+	AchievementStatusRejected          = "rejected"
+	AchievementStatusDeleted           = "deleted"
+)
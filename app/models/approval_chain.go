@@ -0,0 +1,49 @@
+package models
+
+import "time"
+
+// Jenis approver pada satu tahap persetujuan
+const (
+	ApproverAdvisor = "advisor" // dosen wali mahasiswa pemilik prestasi
+	ApproverRole    = "role"    // user dengan role tertentu (misal Kaprodi)
+)
+
+// ApprovalChain: kriteria nil = cocok dengan nilai apa pun
+type ApprovalChain struct {
+	ID               string          `json:"id"`
+	Name             string          `json:"name"`
+	AchievementType  *string         `json:"achievement_type,omitempty"`
+	CompetitionLevel *string         `json:"competition_level,omitempty"`
+	Priority         int             `json:"priority"`
+	IsActive         bool            `json:"is_active"`
+	Stages           []ApprovalStage `json:"stages"`
+	CreatedAt        time.Time       `json:"created_at"`
+	UpdatedAt        time.Time       `json:"updated_at"`
+}
+
+// ApprovalStage juga disalin ke dokumen prestasi saat diajukan (bson)
+type ApprovalStage struct {
+	Order        int     `bson:"order" json:"order"`
+	Name         string  `bson:"name" json:"name"`
+	ApproverType string  `bson:"approverType" json:"approver_type"`
+	RoleID       *string `bson:"roleId,omitempty" json:"role_id,omitempty"`
+}
+
+// DefaultApprovalChain dipakai jika tidak ada chain yang cocok
+var DefaultApprovalChain = ApprovalChain{
+	Name:     "Default",
+	IsActive: true,
+	Stages:   []ApprovalStage{{Order: 1, Name: "Dosen Wali", ApproverType: ApproverAdvisor}},
+}
+
+// ====================================================
+// STAGE APPROVAL (MONGODB, bagian dari Achievement)
+// ====================================================
+type StageApproval struct {
+	ChainID      string    `bson:"chainId,omitempty" json:"chain_id,omitempty"`
+	Stage        int       `bson:"stage" json:"stage"`
+	StageName    string    `bson:"stageName" json:"stage_name"`
+	ApprovedBy   string    `bson:"approvedBy" json:"approved_by"`
+	ApprovedByID string    `bson:"approvedById" json:"approved_by_id"`
+	ApprovedAt   time.Time `bson:"approvedAt" json:"approved_at"`
+}
@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+// Jenis event di log keamanan autentikasi
+const (
+	AuthEventLogin          = "login"
+	AuthEventRefresh        = "refresh"
+	AuthEventLogout         = "logout"
+	AuthEventLogoutAll      = "logout_all"
+	AuthEventPasswordChange = "password_change"
+	AuthEventPasswordReset  = "password_reset"
+	AuthEventLockout        = "lockout"
+)
+
+var AuthEventTypes = []string{
+	AuthEventLogin,
+	AuthEventRefresh,
+	AuthEventLogout,
+	AuthEventLogoutAll,
+	AuthEventPasswordChange,
+	AuthEventPasswordReset,
+	AuthEventLockout,
+}
+
+type AuthEvent struct {
+	ID         string    `json:"id"`
+	EventType  string    `json:"event_type"`
+	Success    bool      `json:"success"`
+	UserID     *string   `json:"user_id,omitempty"`
+	Identifier string    `json:"identifier,omitempty"`
+	SessionID  *string   `json:"session_id,omitempty"`
+	IPAddress  string    `json:"ip_address"`
+	UserAgent  string    `json:"user_agent"`
+	Detail     string    `json:"detail,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// AuthEventFilter dipakai endpoint admin, field kosong = tidak difilter
+type AuthEventFilter struct {
+	UserID     string
+	EventTypes []string
+	Success    *bool
+	From       *time.Time
+	To         *time.Time
+	Limit      int
+	Offset     int
+}
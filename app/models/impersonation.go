@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+type ImpersonationSession struct {
+	ID           string     `json:"id"`
+	AdminID      string     `json:"admin_id"`
+	TargetUserID string     `json:"target_user_id"`
+	Reason       string     `json:"reason"`
+	IPAddress    string     `json:"ip_address"`
+	StartedAt    time.Time  `json:"started_at"`
+	ExpiresAt    time.Time  `json:"expires_at"`
+	EndedAt      *time.Time `json:"ended_at,omitempty"`
+}
+
+type ImpersonationAudit struct {
+	ID              string    `json:"id"`
+	ImpersonationID string    `json:"impersonation_id"`
+	AdminID         string    `json:"admin_id"`
+	TargetUserID    string    `json:"target_user_id"`
+	Method          string    `json:"method"`
+	Path            string    `json:"path"`
+	StatusCode      int       `json:"status_code"`
+	Blocked         bool      `json:"blocked"`
+	IPAddress       string    `json:"ip_address"`
+	CreatedAt       time.Time `json:"created_at"`
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// Status undangan yang ditampilkan di daftar user
+const (
+	InvitationPending  = "pending"
+	InvitationExpired  = "expired"
+	InvitationAccepted = "accepted"
+	InvitationRevoked  = "revoked"
+)
+
+type UserInvitation struct {
+	ID         string     `json:"id"`
+	UserID     string     `json:"user_id"`
+	CreatedBy  *string    `json:"created_by,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	AcceptedAt *time.Time `json:"accepted_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
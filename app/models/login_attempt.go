@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// Alasan percobaan yang ditolak sebelum password dicek. Dicatat untuk audit,
+// tapi tidak dihitung sebagai kegagalan IP agar klien yang mencoba ulang
+// tidak memperpanjang blokirnya sendiri.
+const (
+	LoginReasonIPThrottled   = "ip throttled"
+	LoginReasonBackoff       = "backoff"
+	LoginReasonAccountLocked = "account locked"
+)
+
+var LoginRejectedReasons = []string{LoginReasonIPThrottled, LoginReasonBackoff, LoginReasonAccountLocked}
+
+type LoginAttempt struct {
+	ID          string    `json:"id"`
+	Identifier  string    `json:"identifier"`
+	UserID      *string   `json:"user_id,omitempty"`
+	IPAddress   string    `json:"ip_address"`
+	UserAgent   string    `json:"user_agent"`
+	Success     bool      `json:"success"`
+	Reason      string    `json:"reason,omitempty"`
+	AttemptedAt time.Time `json:"attempted_at"`
+}
+
+type AccountLockout struct {
+	UserID       string     `json:"user_id"`
+	FailedCount  int        `json:"failed_count"`
+	LastFailedAt *time.Time `json:"last_failed_at,omitempty"`
+	LockedUntil  *time.Time `json:"locked_until,omitempty"`
+}
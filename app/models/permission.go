@@ -7,3 +7,88 @@ type Permission struct {
 	Action      string `json:"action"`
 	Description string `json:"description"`
 }
+
+// =====================================================
+// KATALOG PERMISSION (format "resource:action")
+// =====================================================
+// Route & service hanya mengecek permission, bukan nama role, sehingga role
+// baru cukup diberi permission lewat tabel role_permissions.
+const (
+	PermUserManage      = "user:manage"
+	PermUserImpersonate = "user:impersonate"
+	PermStudentManage   = "student:manage"
+	PermLecturerManage  = "lecturer:manage"
+	PermRoleManage      = "role:manage"
+	PermAPIKeyManage    = "apikey:manage"
+	PermScoringManage   = "scoring:manage"
+	PermApprovalManage  = "approval:manage"
+	// menyetujui tahap approval chain mana pun (di luar approver tahap itu)
+	PermApprovalOverride = "approval:override"
+
+	PermAchievementCreate      = "achievement:create"
+	PermAchievementReadOwn     = "achievement:read_own"
+	PermAchievementReadAdvisee = "achievement:read_advisee"
+	PermAchievementReadAll     = "achievement:read_all"
+	PermAchievementUpdate      = "achievement:update"
+	PermAchievementDelete      = "achievement:delete"
+	PermAchievementSubmit      = "achievement:submit"
+	PermAchievementVerify      = "achievement:verify"
+	PermAchievementReject      = "achievement:reject"
+	// meminta revisi dengan komentar per field
+	PermAchievementRequestRevision = "achievement:request_revision"
+
+	PermReportReadOwn     = "report:read_own"
+	PermReportReadAdvisee = "report:read_advisee"
+	PermReportReadAll     = "report:read_all"
+)
+
+// PermissionCatalog di-seed ke tabel permissions saat startup.
+var PermissionCatalog = []Permission{
+	{Name: PermUserManage, Resource: "user", Action: "manage", Description: "Kelola akun user, session, lockout dan MFA"},
+	{Name: PermUserImpersonate, Resource: "user", Action: "impersonate", Description: "Login sebagai user lain (read-only, diaudit)"},
+	{Name: PermStudentManage, Resource: "student", Action: "manage", Description: "Kelola data mahasiswa dan dosen wali"},
+	{Name: PermLecturerManage, Resource: "lecturer", Action: "manage", Description: "Kelola data dosen"},
+	{Name: PermRoleManage, Resource: "role", Action: "manage", Description: "Kelola role dan permission"},
+
+	{Name: PermAPIKeyManage, Resource: "apikey", Action: "manage", Description: "Kelola API key integrasi sistem"},
+	{Name: PermScoringManage, Resource: "scoring", Action: "manage", Description: "Kelola rulebook poin prestasi dan hitung ulang poin"},
+	{Name: PermApprovalManage, Resource: "approval", Action: "manage", Description: "Kelola approval chain bertingkat untuk verifikasi prestasi"},
+	{Name: PermApprovalOverride, Resource: "approval", Action: "override", Description: "Menyetujui tahap approval chain mana pun"},
+
+	{Name: PermAchievementCreate, Resource: "achievement", Action: "create", Description: "Membuat draft prestasi milik sendiri"},
+	{Name: PermAchievementReadOwn, Resource: "achievement", Action: "read_own", Description: "Melihat prestasi milik sendiri"},
+	{Name: PermAchievementReadAdvisee, Resource: "achievement", Action: "read_advisee", Description: "Melihat prestasi mahasiswa bimbingan"},
+	{Name: PermAchievementReadAll, Resource: "achievement", Action: "read_all", Description: "Melihat seluruh prestasi"},
+	{Name: PermAchievementUpdate, Resource: "achievement", Action: "update", Description: "Mengubah draft prestasi dan lampiran"},
+	{Name: PermAchievementDelete, Resource: "achievement", Action: "delete", Description: "Menghapus draft prestasi"},
+	{Name: PermAchievementSubmit, Resource: "achievement", Action: "submit", Description: "Mengajukan prestasi untuk diverifikasi"},
+	{Name: PermAchievementVerify, Resource: "achievement", Action: "verify", Description: "Memverifikasi prestasi"},
+	{Name: PermAchievementReject, Resource: "achievement", Action: "reject", Description: "Menolak prestasi"},
+	{Name: PermAchievementRequestRevision, Resource: "achievement", Action: "request_revision", Description: "Meminta revisi prestasi dengan komentar per field"},
+
+	{Name: PermReportReadOwn, Resource: "report", Action: "read_own", Description: "Melihat laporan prestasi sendiri"},
+	{Name: PermReportReadAdvisee, Resource: "report", Action: "read_advisee", Description: "Melihat laporan mahasiswa bimbingan"},
+	{Name: PermReportReadAll, Resource: "report", Action: "read_all", Description: "Melihat laporan seluruh mahasiswa"},
+}
+
+// DefaultRolePermissions diberikan ke role bawaan hanya ketika permission
+// baru pertama kali di-seed, agar perubahan manual admin tidak tertimpa.
+var DefaultRolePermissions = map[string][]string{
+	"Mahasiswa": {
+		PermAchievementCreate, PermAchievementReadOwn, PermAchievementUpdate,
+		PermAchievementDelete, PermAchievementSubmit, PermReportReadOwn,
+	},
+	"Dosen Wali": {
+		PermAchievementReadAdvisee, PermAchievementVerify, PermAchievementReject,
+		PermAchievementRequestRevision, PermReportReadAdvisee,
+	},
+}
+
+// NonDelegablePermissions tidak boleh diberikan ke API key agar integrasi
+// mesin tidak bisa mengelola akun, role, atau key lain.
+var NonDelegablePermissions = []string{
+	PermUserManage, PermUserImpersonate, PermRoleManage, PermAPIKeyManage,
+}
+
+// AdminRoleName selalu mendapat seluruh permission di katalog.
+const AdminRoleName = "Admin"
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// ScoringRule: kriteria nil = cocok dengan nilai apa pun
+type ScoringRule struct {
+	ID               string    `json:"id"`
+	Name             string    `json:"name"`
+	AchievementType  *string   `json:"achievement_type,omitempty"`
+	CompetitionLevel *string   `json:"competition_level,omitempty"`
+	Rank             *int      `json:"rank,omitempty"`
+	MedalType        *string   `json:"medal_type,omitempty"`
+	PublicationType  *string   `json:"publication_type,omitempty"`
+	Position         *string   `json:"position,omitempty"`
+	Points           int       `json:"points"`
+	Priority         int       `json:"priority"`
+	IsActive         bool      `json:"is_active"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// Session = satu keluarga refresh token (satu login di satu perangkat)
+type Session struct {
+	ID           string     `json:"id"`
+	UserID       string     `json:"user_id"`
+	RefreshJTI   string     `json:"-"`
+	UserAgent    string     `json:"user_agent"`
+	IPAddress    string     `json:"ip_address"`
+	CreatedAt    time.Time  `json:"created_at"`
+	LastUsedAt   time.Time  `json:"last_used_at"`
+	ExpiresAt    time.Time  `json:"expires_at"`
+	RevokedAt    *time.Time `json:"revoked_at,omitempty"`
+	RevokeReason *string    `json:"revoke_reason,omitempty"`
+}
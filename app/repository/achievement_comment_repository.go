@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"achievements-uas/app/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+/*
+=====================================================
+ACHIEVEMENT COMMENTS (thread diskusi per prestasi)
+=====================================================
+*/
+type AchievementCommentRepository struct {
+	collection *mongo.Collection
+}
+
+func NewAchievementCommentRepository(db *mongo.Database) *AchievementCommentRepository {
+	return &AchievementCommentRepository{
+		collection: db.Collection("achievement_comments"),
+	}
+}
+
+func (r *AchievementCommentRepository) Create(ctx context.Context, cm *models.AchievementComment) error {
+	cm.ID = primitive.NewObjectID()
+	_, err := r.collection.InsertOne(ctx, cm)
+	return err
+}
+
+// FindByAchievement mengembalikan komentar urut waktu (termasuk yang dihapus)
+func (r *AchievementCommentRepository) FindByAchievement(ctx context.Context, achievementID string) ([]models.AchievementComment, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}})
+	cursor, err := r.collection.Find(ctx, bson.M{"achievementId": achievementID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	results := []models.AchievementComment{}
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func (r *AchievementCommentRepository) FindByID(ctx context.Context, achievementID, id string) (*models.AchievementComment, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, mongo.ErrNoDocuments
+	}
+	var cm models.AchievementComment
+	err = r.collection.FindOne(ctx, bson.M{"_id": oid, "achievementId": achievementID}).Decode(&cm)
+	if err != nil {
+		return nil, err
+	}
+	return &cm, nil
+}
+
+func (r *AchievementCommentRepository) UpdateBody(ctx context.Context, id primitive.ObjectID, body string, mentions []models.CommentMention, at time.Time) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id, "deletedAt": bson.M{"$exists": false}}, bson.M{
+		"$set": bson.M{"body": body, "mentions": mentions, "editedAt": at},
+	})
+	return err
+}
+
+// SoftDelete mengosongkan isi komentar tapi tetap menyimpan jejaknya di thread
+func (r *AchievementCommentRepository) SoftDelete(ctx context.Context, id primitive.ObjectID, at time.Time) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{
+		"$set":   bson.M{"body": "", "deletedAt": at},
+		"$unset": bson.M{"mentions": ""},
+	})
+	return err
+}
@@ -107,6 +107,26 @@ func (r *AchievementMongoRepository) Update(ctx context.Context, id primitive.Ob
 
 	return nil
 }
+/*
+=====================================================
+UPDATE IF STATUS (transisi workflow)
+=====================================================
+*/
+// UpdateIfStatus hanya meng-update jika status dokumen masih sama dengan
+// fromStatus, sehingga dua transisi bersamaan tidak saling menimpa.
+// match (boleh nil) menambah syarat lain, misal tahap approval yang sedang berjalan.
+func (r *AchievementMongoRepository) UpdateIfStatus(ctx context.Context, id primitive.ObjectID, fromStatus string, match bson.M, update bson.M) (bool, error) {
+	filter := bson.M{"_id": id, "status": fromStatus}
+	for k, v := range match {
+		filter[k] = v
+	}
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount == 1, nil
+}
+
 /*
 =====================================================
 QUERY BY STUDENT ID (Filtered by Soft Delete)
@@ -187,8 +207,10 @@ func (r *AchievementMongoRepository) SoftDelete(ctx context.Context, id primitiv
 ADD ATTACHMENT
 =====================================================
 */
-func (r *AchievementMongoRepository) AddAttachment(ctx context.Context,id primitive.ObjectID,attachment models.Attachment,
-) error {
+// AddAttachment hanya berlaku jika status prestasi masih salah satu dari
+// statuses; false = status sudah berubah (atau dokumen tidak ada)
+func (r *AchievementMongoRepository) AddAttachment(ctx context.Context,id primitive.ObjectID,statuses []string,attachment models.Attachment,
+) (bool, error) {
 
 	update := bson.M{
 		"$push": bson.M{
@@ -199,8 +221,12 @@ func (r *AchievementMongoRepository) AddAttachment(ctx context.Context,id primit
 		},
 	}
 
-	_, err := r.collection.UpdateByID(ctx, id, update)
-	return err
+	filter := bson.M{"_id": id, "status": bson.M{"$in": statuses}}
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount == 1, nil
 }
 
 
@@ -275,4 +301,31 @@ func (r *AchievementPostgresRepository) GetByMongoID(ctx context.Context, mongoI
         return nil, err
     }
     return &ref, nil
-}
\ No newline at end of file
+}
+
+/*
+=====================================================
+SCORING
+=====================================================
+*/
+func (r *AchievementMongoRepository) FindByStatus(ctx context.Context, status string) ([]models.Achievement, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{"status": status})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []models.Achievement
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// SetScore menyimpan poin & rule; ruleID kosong = tidak ada rule yang cocok
+func (r *AchievementMongoRepository) SetScore(ctx context.Context, id primitive.ObjectID, points int, ruleID string) error {
+	_, err := r.collection.UpdateByID(ctx, id, bson.M{
+		"$set": bson.M{"points": points, "scoringRuleId": ruleID},
+	})
+	return err
+}
@@ -100,6 +100,7 @@ func (r *AchievementPostgresRepository) UpdateToSubmitted(ctx context.Context, m
         SET 
             status = 'submitted', 
             submitted_at = NOW(), 
+            approval_stage = 0,
             updated_at = NOW() 
         WHERE mongo_achievement_id = $1`
     
@@ -337,3 +338,78 @@ func (r *AchievementPostgresRepository) GetAllWithCount(
 
 	return results, total, nil
 }
+
+/*
+=====================================================
+SCORING: poin & rule yang dipakai
+=====================================================
+*/
+func (r *AchievementPostgresRepository) SetScore(ctx context.Context, mongoID string, points int, ruleID *string) error {
+	query := `
+		UPDATE achievement_references
+		SET points=$1, scoring_rule_id=$2, scored_at=NOW()
+		WHERE mongo_achievement_id=$3
+	`
+	_, err := r.db.ExecContext(ctx, query, points, ruleID, mongoID)
+	return err
+}
+
+/*
+=====================================================
+APPROVAL CHAIN: catat persetujuan per tahap
+=====================================================
+*/
+// StageApprovalRecord: satu baris achievement_approvals beserta status
+// reference setelah tahap disetujui (partially_approved / verified)
+type StageApprovalRecord struct {
+	ID         string
+	MongoID    string
+	ChainID    *string
+	Stage      int
+	StageName  string
+	ApprovedBy string
+	Status     string
+}
+
+// RecordStageApproval menyimpan jejak tahap di achievement_approvals dan
+// memperbarui status & approval_stage pada reference dalam satu transaksi.
+// confirm (misal update MongoDB) dijalankan sebelum commit; jika gagal,
+// seluruh perubahan PostgreSQL dibatalkan.
+func (r *AchievementPostgresRepository) RecordStageApproval(ctx context.Context, rec StageApprovalRecord, confirm func() error) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO achievement_approvals (id, mongo_achievement_id, chain_id, stage_order, stage_name, approved_by, approved_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NOW())
+	`, rec.ID, rec.MongoID, rec.ChainID, rec.Stage, rec.StageName, rec.ApprovedBy); err != nil {
+		return err
+	}
+	res, err := tx.ExecContext(ctx, `
+		UPDATE achievement_references
+		SET status=$1, approval_stage=$2, approval_chain_id=$3, updated_at=NOW()
+		WHERE mongo_achievement_id=$4
+	`, rec.Status, rec.Stage, rec.ChainID, rec.MongoID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("no rows updated for mongo_id: %s", rec.MongoID)
+	}
+	if rec.Status == models.AchievementStatusVerified {
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE achievement_references SET verified_at=NOW(), verified_by=$1
+			WHERE mongo_achievement_id=$2
+		`, rec.ApprovedBy, rec.MongoID); err != nil {
+			return err
+		}
+	}
+
+	if err := confirm(); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -2,7 +2,10 @@ package repository
 
 import (
 	"database/sql"
+	"errors"
 	"achievements-uas/app/models"
+
+	"github.com/lib/pq"
 )
 
 type AdminRepository struct {
@@ -13,6 +16,9 @@ func NewAdminRepository(db *sql.DB) *AdminRepository {
 	return &AdminRepository{DB: db}
 }
 
+// ErrProfileNotFound: NIM / NIDN diubah untuk user tanpa profil mahasiswa / dosen
+var ErrProfileNotFound = errors.New("user has no matching student or lecturer profile")
+
 //
 // ================= USER =================
 //
@@ -71,12 +77,42 @@ func (r *AdminRepository) CreateLecturer(u *models.User, l *models.Lecturer) err
     return tx.Commit()
 }
 
+// IdentifierTaken mengecek apakah value sudah dipakai sebagai identifier login
+// (username, email, NIM atau NIDN) oleh user lain selain excludeUserID.
+func (r *AdminRepository) IdentifierTaken(value, excludeUserID string) (bool, error) {
+	var taken bool
+	err := r.DB.QueryRow(`
+		SELECT EXISTS (
+			SELECT 1 FROM users WHERE (username=$1 OR email=$1) AND id::text <> $2
+			UNION ALL SELECT 1 FROM students WHERE student_id=$1 AND user_id::text <> $2
+			UNION ALL SELECT 1 FROM lecturers WHERE lecturer_id=$1 AND user_id::text <> $2
+		)
+	`, value, excludeUserID).Scan(&taken)
+	return taken, err
+}
+
+// Undangan terakhir per user, dipakai untuk kolom status di daftar user
+const latestInvitationJoin = `
+		LEFT JOIN LATERAL (
+			SELECT expires_at, accepted_at, revoked_at FROM user_invitations
+			WHERE user_id = u.id ORDER BY created_at DESC LIMIT 1
+		) inv ON true`
+
+const invitationStatusSQL = `CASE
+			WHEN inv.expires_at IS NULL THEN ''
+			WHEN inv.accepted_at IS NOT NULL THEN 'accepted'
+			WHEN inv.revoked_at IS NOT NULL THEN 'revoked'
+			WHEN inv.expires_at <= NOW() THEN 'expired'
+			ELSE 'pending' END`
+
 // GET ALL USERS
 func (r *AdminRepository) GetAllUsers() ([]models.User, error) {
 	q := `
-		SELECT id, username, email, full_name, role_id, is_active, created_at, updated_at
-		FROM users
-		ORDER BY created_at DESC
+		SELECT u.id, u.username, u.email, u.full_name, u.role_id, u.is_active, u.created_at, u.updated_at,
+		       ` + invitationStatusSQL + `, inv.expires_at
+		FROM users u
+		` + latestInvitationJoin + `
+		ORDER BY u.created_at DESC
 	`
 	rows, err := r.DB.Query(q)
 	if err != nil {
@@ -91,6 +127,7 @@ func (r *AdminRepository) GetAllUsers() ([]models.User, error) {
 			&u.ID, &u.Username, &u.Email,
 			&u.FullName, &u.RoleID, &u.IsActive,
 			&u.CreatedAt, &u.UpdatedAt,
+			&u.InvitationStatus, &u.InvitationExpiresAt,
 		); err != nil {
 			return nil, err
 		}
@@ -102,14 +139,18 @@ func (r *AdminRepository) GetAllUsers() ([]models.User, error) {
 // GET USER BY ID
 func (r *AdminRepository) GetByID(id string) (*models.User, error) {
 	q := `
-		SELECT id, username, email, full_name, role_id, is_active, created_at, updated_at
-		FROM users WHERE id=$1
+		SELECT u.id, u.username, u.email, u.full_name, u.role_id, u.is_active, u.created_at, u.updated_at,
+		       ` + invitationStatusSQL + `, inv.expires_at
+		FROM users u
+		` + latestInvitationJoin + `
+		WHERE u.id=$1
 	`
 	u := &models.User{}
 	err := r.DB.QueryRow(q, id).Scan(
 		&u.ID, &u.Username, &u.Email,
 		&u.FullName, &u.RoleID, &u.IsActive,
 		&u.CreatedAt, &u.UpdatedAt,
+		&u.InvitationStatus, &u.InvitationExpiresAt,
 	)
 	if err != nil {
 		return nil, err
@@ -146,17 +187,43 @@ func (r *AdminRepository) GetStudentByUserID(userID string) (*models.Student, er
 }
 
 // UPDATE USER
-func (r *AdminRepository) UpdateUser(u *models.User) error {
+// UpdateUser; studentID / lecturerID kosong = NIM / NIDN tidak diubah
+func (r *AdminRepository) UpdateUser(u *models.User, studentID, lecturerID string) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
 	q := `
 		UPDATE users
 		SET username=$2, email=$3, full_name=$4, role_id=$5, is_active=$6, updated_at=NOW()
 		WHERE id=$1
 	`
-	_, err := r.DB.Exec(q,
+	if _, err := tx.Exec(q,
 		u.ID, u.Username, u.Email,
 		u.FullName, u.RoleID, u.IsActive,
-	)
-	return err
+	); err != nil {
+		return err
+	}
+
+	profiles := []struct{ query, value string }{
+		{`UPDATE students SET student_id=$2 WHERE user_id=$1`, studentID},
+		{`UPDATE lecturers SET lecturer_id=$2 WHERE user_id=$1`, lecturerID},
+	}
+	for _, p := range profiles {
+		if p.value == "" {
+			continue
+		}
+		res, err := tx.Exec(p.query, u.ID, p.value)
+		if err != nil {
+			return err
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return ErrProfileNotFound
+		}
+	}
+	return tx.Commit()
 }
 
 func (r *AdminRepository) DeleteUser(id string) error {
@@ -387,17 +454,68 @@ func (r *AdminRepository) GetAllLecturers() ([]models.Lecturer, error) {
 	}
 	return list, nil
 }
-func (r *AdminRepository) CheckIsAdvisee(studentID, lecturerID string) (bool, error) {
+// CheckIsAdvisee: studentNIM = students.student_id (NIM di prestasi),
+// lecturerID = user id dosen wali (kunci advisor_id yang sama dengan GetLecturerAdvisees)
+func (r *AdminRepository) CheckIsAdvisee(studentNIM, lecturerID string) (bool, error) {
     var exists bool
-    // Sesuaikan nama kolom 'advisor_id' dengan nama kolom di tabel students milikmu
-    query := `SELECT EXISTS(SELECT 1 FROM students WHERE id = $1 AND advisor_id = $2)`
+    query := `SELECT EXISTS(SELECT 1 FROM students WHERE student_id = $1 AND advisor_id = $2)`
     
-    err := r.DB.QueryRow(query, studentID, lecturerID).Scan(&exists)
+    err := r.DB.QueryRow(query, studentNIM, lecturerID).Scan(&exists)
     if err != nil {
         return false, err
     }
     return exists, nil
 }
+// GET ACHIEVEMENT PARTICIPANTS
+// user_id mahasiswa pemilik NIM dan dosen walinya (advisor_id = user id dosen wali)
+func (r *AdminRepository) GetAchievementParticipantIDs(studentNIM string) ([]string, error) {
+	q := `
+		SELECT s.user_id FROM students s WHERE s.student_id = $1
+		UNION
+		SELECT s.advisor_id FROM students s
+		WHERE s.student_id = $1 AND s.advisor_id IS NOT NULL
+	`
+	rows, err := r.DB.Query(q, studentNIM)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// FIND ACTIVE USERS BY USERNAMES (resolusi @mention)
+func (r *AdminRepository) FindActiveUsersByUsernames(usernames []string) ([]models.User, error) {
+	q := `
+		SELECT id, username, email, full_name
+		FROM users
+		WHERE username = ANY($1) AND is_active = TRUE
+	`
+	rows, err := r.DB.Query(q, pq.Array(usernames))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []models.User{}
+	for rows.Next() {
+		var u models.User
+		if err := rows.Scan(&u.ID, &u.Username, &u.Email, &u.FullName); err != nil {
+			return nil, err
+		}
+		list = append(list, u)
+	}
+	return list, rows.Err()
+}
+
 // GET LECTURER ADVISEES
 func (r *AdminRepository) GetLecturerAdvisees(lecturerID string) ([]models.Student, error) {
     q := `
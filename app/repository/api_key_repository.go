@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"database/sql"
+
+	"achievements-uas/app/models"
+
+	"github.com/lib/pq"
+)
+
+type APIKeyRepository struct {
+	DB *sql.DB
+}
+
+func NewAPIKeyRepository(db *sql.DB) *APIKeyRepository {
+	return &APIKeyRepository{DB: db}
+}
+
+const apiKeyColumns = `id, name, prefix, key_hash, scopes, allowed_ips, created_by,
+	created_at, expires_at, last_used_at, last_used_ip, revoked_at`
+
+func scanAPIKey(row interface{ Scan(...interface{}) error }) (*models.APIKey, error) {
+	var k models.APIKey
+	if err := row.Scan(&k.ID, &k.Name, &k.Prefix, &k.KeyHash, pq.Array(&k.Scopes), pq.Array(&k.AllowedIPs),
+		&k.CreatedBy, &k.CreatedAt, &k.ExpiresAt, &k.LastUsedAt, &k.LastUsedIP, &k.RevokedAt); err != nil {
+		return nil, err
+	}
+	return &k, nil
+}
+
+func (r *APIKeyRepository) Create(k *models.APIKey) error {
+	_, err := r.DB.Exec(`
+		INSERT INTO api_keys (id, name, prefix, key_hash, scopes, allowed_ips, created_by, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, NOW(), $8)
+	`, k.ID, k.Name, k.Prefix, k.KeyHash, pq.Array(k.Scopes), pq.Array(k.AllowedIPs), k.CreatedBy, k.ExpiresAt)
+	return err
+}
+
+// FindByHash dipakai AuthRequired (utils.APIKeyStore)
+func (r *APIKeyRepository) FindByHash(hash string) (*models.APIKey, error) {
+	return scanAPIKey(r.DB.QueryRow(`SELECT `+apiKeyColumns+` FROM api_keys WHERE key_hash=$1`, hash))
+}
+
+func (r *APIKeyRepository) FindByID(id string) (*models.APIKey, error) {
+	return scanAPIKey(r.DB.QueryRow(`SELECT `+apiKeyColumns+` FROM api_keys WHERE id=$1`, id))
+}
+
+func (r *APIKeyRepository) FindAll() ([]models.APIKey, error) {
+	rows, err := r.DB.Query(`SELECT ` + apiKeyColumns + ` FROM api_keys ORDER BY created_at DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []models.APIKey{}
+	for rows.Next() {
+		k, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *k)
+	}
+	return list, rows.Err()
+}
+
+func (r *APIKeyRepository) TouchLastUsed(id, ip string) error {
+	_, err := r.DB.Exec(`
+		UPDATE api_keys SET last_used_at=NOW(), last_used_ip=$2 WHERE id=$1
+	`, id, ip)
+	return err
+}
+
+// Revoke mengembalikan false jika key tidak ada / sudah di-revoke
+func (r *APIKeyRepository) Revoke(id string) (bool, error) {
+	res, err := r.DB.Exec(`
+		UPDATE api_keys SET revoked_at=NOW() WHERE id=$1 AND revoked_at IS NULL
+	`, id)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
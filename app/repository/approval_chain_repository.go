@@ -0,0 +1,179 @@
+package repository
+
+import (
+	"database/sql"
+
+	"achievements-uas/app/models"
+
+	"github.com/lib/pq"
+)
+
+type ApprovalChainRepository struct {
+	DB *sql.DB
+}
+
+func NewApprovalChainRepository(db *sql.DB) *ApprovalChainRepository {
+	return &ApprovalChainRepository{DB: db}
+}
+
+const approvalChainColumns = `id, name, achievement_type, competition_level, priority, is_active, created_at, updated_at`
+
+func scanApprovalChain(row rowScanner) (*models.ApprovalChain, error) {
+	var ch models.ApprovalChain
+	if err := row.Scan(&ch.ID, &ch.Name, &ch.AchievementType, &ch.CompetitionLevel,
+		&ch.Priority, &ch.IsActive, &ch.CreatedAt, &ch.UpdatedAt); err != nil {
+		return nil, err
+	}
+	ch.Stages = []models.ApprovalStage{}
+	return &ch, nil
+}
+
+// loadStages mengisi Stages untuk semua chain sekaligus (urut stage_order)
+func (r *ApprovalChainRepository) loadStages(chains []*models.ApprovalChain) error {
+	if len(chains) == 0 {
+		return nil
+	}
+	byID := map[string]*models.ApprovalChain{}
+	ids := make([]string, 0, len(chains))
+	for _, ch := range chains {
+		byID[ch.ID] = ch
+		ids = append(ids, ch.ID)
+	}
+
+	rows, err := r.DB.Query(`
+		SELECT chain_id, stage_order, name, approver_type, role_id
+		FROM approval_chain_stages
+		WHERE chain_id = ANY($1::uuid[])
+		ORDER BY chain_id, stage_order
+	`, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var chainID string
+		var st models.ApprovalStage
+		if err := rows.Scan(&chainID, &st.Order, &st.Name, &st.ApproverType, &st.RoleID); err != nil {
+			return err
+		}
+		if ch := byID[chainID]; ch != nil {
+			ch.Stages = append(ch.Stages, st)
+		}
+	}
+	return rows.Err()
+}
+
+// FindAll; activeOnly=true untuk pemilihan chain saat prestasi diajukan
+func (r *ApprovalChainRepository) FindAll(activeOnly bool) ([]models.ApprovalChain, error) {
+	rows, err := r.DB.Query(`
+		SELECT `+approvalChainColumns+` FROM approval_chains
+		WHERE is_active OR NOT $1
+		ORDER BY priority DESC, name
+	`, activeOnly)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ptrs := []*models.ApprovalChain{}
+	for rows.Next() {
+		ch, err := scanApprovalChain(rows)
+		if err != nil {
+			return nil, err
+		}
+		ptrs = append(ptrs, ch)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if err := r.loadStages(ptrs); err != nil {
+		return nil, err
+	}
+
+	list := make([]models.ApprovalChain, 0, len(ptrs))
+	for _, ch := range ptrs {
+		list = append(list, *ch)
+	}
+	return list, nil
+}
+
+func (r *ApprovalChainRepository) FindByID(id string) (*models.ApprovalChain, error) {
+	ch, err := scanApprovalChain(r.DB.QueryRow(`SELECT `+approvalChainColumns+` FROM approval_chains WHERE id=$1`, id))
+	if err != nil {
+		return nil, err
+	}
+	if err := r.loadStages([]*models.ApprovalChain{ch}); err != nil {
+		return nil, err
+	}
+	return ch, nil
+}
+
+func insertStages(tx *sql.Tx, chainID string, stages []models.ApprovalStage) error {
+	for _, st := range stages {
+		if _, err := tx.Exec(`
+			INSERT INTO approval_chain_stages (chain_id, stage_order, name, approver_type, role_id)
+			VALUES ($1, $2, $3, $4, $5)
+		`, chainID, st.Order, st.Name, st.ApproverType, st.RoleID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *ApprovalChainRepository) Create(ch *models.ApprovalChain) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`
+		INSERT INTO approval_chains (id, name, achievement_type, competition_level, priority, is_active, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NOW(), NOW())
+	`, ch.ID, ch.Name, ch.AchievementType, ch.CompetitionLevel, ch.Priority, ch.IsActive); err != nil {
+		return err
+	}
+	if err := insertStages(tx, ch.ID, ch.Stages); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Update mengganti data chain beserta seluruh tahapnya
+func (r *ApprovalChainRepository) Update(ch *models.ApprovalChain) (bool, error) {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`
+		UPDATE approval_chains SET name=$2, achievement_type=$3, competition_level=$4,
+			priority=$5, is_active=$6, updated_at=NOW()
+		WHERE id=$1
+	`, ch.ID, ch.Name, ch.AchievementType, ch.CompetitionLevel, ch.Priority, ch.IsActive)
+	if err != nil {
+		return false, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return false, nil
+	}
+
+	if _, err := tx.Exec(`DELETE FROM approval_chain_stages WHERE chain_id=$1`, ch.ID); err != nil {
+		return false, err
+	}
+	if err := insertStages(tx, ch.ID, ch.Stages); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+func (r *ApprovalChainRepository) Delete(id string) (bool, error) {
+	res, err := r.DB.Exec(`DELETE FROM approval_chains WHERE id=$1`, id)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
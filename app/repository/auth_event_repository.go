@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"achievements-uas/app/models"
+
+	"github.com/lib/pq"
+)
+
+type AuthEventRepository struct {
+	DB *sql.DB
+}
+
+func NewAuthEventRepository(db *sql.DB) *AuthEventRepository {
+	return &AuthEventRepository{DB: db}
+}
+
+//
+// ================= AUTH EVENTS (append-only) =================
+//
+
+func (r *AuthEventRepository) Record(e *models.AuthEvent) error {
+	_, err := r.DB.Exec(`
+		INSERT INTO auth_events (id, event_type, success, user_id, identifier, session_id, ip_address, user_agent, detail, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NOW())
+	`, e.ID, e.EventType, e.Success, e.UserID, e.Identifier, e.SessionID, e.IPAddress, e.UserAgent, e.Detail)
+	return err
+}
+
+// List mengembalikan event terbaru sesuai filter beserta total baris yang cocok
+func (r *AuthEventRepository) List(f models.AuthEventFilter) ([]models.AuthEvent, int, error) {
+	var where []string
+	var args []interface{}
+	add := func(cond string, v interface{}) {
+		args = append(args, v)
+		where = append(where, fmt.Sprintf(cond, len(args)))
+	}
+
+	if f.UserID != "" {
+		add("user_id::text = $%d", f.UserID)
+	}
+	if len(f.EventTypes) > 0 {
+		add("event_type = ANY($%d)", pq.Array(f.EventTypes))
+	}
+	if f.Success != nil {
+		add("success = $%d", *f.Success)
+	}
+	if f.From != nil {
+		add("created_at >= $%d", *f.From)
+	}
+	if f.To != nil {
+		add("created_at < $%d", *f.To)
+	}
+
+	cond := ""
+	if len(where) > 0 {
+		cond = "WHERE " + strings.Join(where, " AND ")
+	}
+
+	var total int
+	if err := r.DB.QueryRow(`SELECT COUNT(*) FROM auth_events `+cond, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	args = append(args, f.Limit, f.Offset)
+	rows, err := r.DB.Query(fmt.Sprintf(`
+		SELECT id, event_type, success, user_id, identifier, session_id, ip_address, user_agent, detail, created_at
+		FROM auth_events %s
+		ORDER BY created_at DESC
+		LIMIT $%d OFFSET $%d
+	`, cond, len(args)-1, len(args)), args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	list := []models.AuthEvent{}
+	for rows.Next() {
+		var e models.AuthEvent
+		if err := rows.Scan(&e.ID, &e.EventType, &e.Success, &e.UserID, &e.Identifier,
+			&e.SessionID, &e.IPAddress, &e.UserAgent, &e.Detail, &e.CreatedAt); err != nil {
+			return nil, 0, err
+		}
+		list = append(list, e)
+	}
+	return list, total, rows.Err()
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"database/sql"
+	"errors"
 	"achievements-uas/app/models"
 )
 
@@ -15,43 +16,153 @@ func NewAuthRepository(db *sql.DB) *AuthRepository {
 
 func (r *AuthRepository) FindByEmail(email string) (*models.User, error) {
 	row := r.DB.QueryRow(`
-		SELECT id, username, email, password_hash, full_name, role_id, is_active, created_at, updated_at
+		SELECT id, username, email, password_hash, full_name, role_id, is_active, auth_source, created_at, updated_at
 		FROM users WHERE email=$1
 	`, email)
 
 	var u models.User
 	if err := row.Scan(&u.ID, &u.Username, &u.Email, &u.PasswordHash,
-		&u.FullName, &u.RoleID, &u.IsActive, &u.CreatedAt, &u.UpdatedAt); err != nil {
+		&u.FullName, &u.RoleID, &u.IsActive, &u.AuthSource, &u.CreatedAt, &u.UpdatedAt); err != nil {
 		return nil, err
 	}
 	return &u, nil
 }
 
+// ErrAmbiguousIdentifier: identifier login cocok dengan lebih dari satu user
+// (misal NIM mahasiswa sama dengan username user lain)
+var ErrAmbiguousIdentifier = errors.New("login identifier matches more than one user")
+
+// GetByUsernameOrEmail mencari user dari identifier login: username, email,
+// NIM (students.student_id) atau NIDN (lecturers.lecturer_id).
 func (r *AuthRepository) GetByUsernameOrEmail(input string) (*models.User, error) {
-	row := r.DB.QueryRow(`
-		SELECT id, username, email, password_hash, full_name, role_id, is_active, created_at, updated_at
-		FROM users WHERE username=$1 OR email=$1 LIMIT 1
+	rows, err := r.DB.Query(`
+		SELECT id, username, email, password_hash, full_name, role_id, is_active, auth_source, created_at, updated_at
+		FROM users
+		WHERE id IN (
+			SELECT id FROM users WHERE username=$1 OR email=$1
+			UNION SELECT user_id FROM students WHERE student_id=$1
+			UNION SELECT user_id FROM lecturers WHERE lecturer_id=$1
+		)
+		LIMIT 2
 	`, input)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
 
-	var u models.User
-	if err := row.Scan(&u.ID, &u.Username, &u.Email, &u.PasswordHash,
-		&u.FullName, &u.RoleID, &u.IsActive, &u.CreatedAt, &u.UpdatedAt); err != nil {
+	var found []models.User
+	for rows.Next() {
+		var u models.User
+		if err := rows.Scan(&u.ID, &u.Username, &u.Email, &u.PasswordHash,
+			&u.FullName, &u.RoleID, &u.IsActive, &u.AuthSource, &u.CreatedAt, &u.UpdatedAt); err != nil {
+			return nil, err
+		}
+		found = append(found, u)
+	}
+	if err := rows.Err(); err != nil {
 		return nil, err
 	}
 
-	return &u, nil
+	switch len(found) {
+	case 0:
+		return nil, sql.ErrNoRows
+	case 1:
+		return &found[0], nil
+	default:
+		return nil, ErrAmbiguousIdentifier
+	}
 }
 
 func (r *AuthRepository) GetProfile(id string) (*models.User, error) {
 	row := r.DB.QueryRow(`
-		SELECT id, username, email, full_name, role_id, is_active, created_at, updated_at
+		SELECT id, username, email, full_name, role_id, is_active, auth_source, created_at, updated_at
 		FROM users WHERE id=$1
 	`, id)
 
 	var u models.User
 	if err := row.Scan(&u.ID, &u.Username, &u.Email,
-		&u.FullName, &u.RoleID, &u.IsActive, &u.CreatedAt, &u.UpdatedAt); err != nil {
+		&u.FullName, &u.RoleID, &u.IsActive, &u.AuthSource, &u.CreatedAt, &u.UpdatedAt); err != nil {
 		return nil, err
 	}
 	return &u, nil
 }
+
+func (r *AuthRepository) GetPasswordHash(id string) (string, error) {
+	var hash string
+	err := r.DB.QueryRow(`SELECT password_hash FROM users WHERE id=$1`, id).Scan(&hash)
+	return hash, err
+}
+
+func (r *AuthRepository) UpdatePassword(id, hash string) error {
+	_, err := r.DB.Exec(`
+		UPDATE users SET password_hash=$2, updated_at=NOW()
+		WHERE id=$1
+	`, id, hash)
+	return err
+}
+
+// CreateSSOUser dipakai provisioning OIDC (password tidak bisa dipakai login)
+func (r *AuthRepository) CreateSSOUser(u *models.User) error {
+	_, err := r.DB.Exec(`
+		INSERT INTO users (id, username, email, password_hash, full_name, role_id, is_active, created_at, updated_at)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,NOW(),NOW())
+	`, u.ID, u.Username, u.Email, u.PasswordHash, u.FullName, u.RoleID, u.IsActive)
+	return err
+}
+
+// UsernameExists juga mengecek NIM/NIDN agar username baru tidak bentrok
+// dengan identifier login milik user lain
+func (r *AuthRepository) UsernameExists(username string) (bool, error) {
+	var exists bool
+	err := r.DB.QueryRow(`
+		SELECT EXISTS (
+			SELECT 1 FROM users WHERE username=$1 OR email=$1
+			UNION ALL SELECT 1 FROM students WHERE student_id=$1
+			UNION ALL SELECT 1 FROM lecturers WHERE lecturer_id=$1
+		)
+	`, username).Scan(&exists)
+	return exists, err
+}
+
+func (r *AuthRepository) SetAuthSource(id, source string) error {
+	res, err := r.DB.Exec(`UPDATE users SET auth_source=$2, updated_at=NOW() WHERE id=$1`, id, source)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// SyncDirectoryProfile menyalin atribut direktori (LDAP) ke users & lecturers.
+// Nilai kosong tidak menimpa data yang sudah ada.
+func (r *AuthRepository) SyncDirectoryProfile(userID, fullName, email, department string) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`
+		UPDATE users
+		SET full_name = COALESCE(NULLIF($2, ''), full_name),
+		    email     = COALESCE(NULLIF($3, ''), email),
+		    updated_at = NOW()
+		WHERE id=$1
+		  AND (full_name IS DISTINCT FROM COALESCE(NULLIF($2, ''), full_name)
+		       OR email IS DISTINCT FROM COALESCE(NULLIF($3, ''), email))
+	`, userID, fullName, email); err != nil {
+		return err
+	}
+
+	if department != "" {
+		if _, err := tx.Exec(`
+			UPDATE lecturers SET department=$2 WHERE user_id=$1 AND department IS DISTINCT FROM $2
+		`, userID, department); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"database/sql"
+
+	"achievements-uas/app/models"
+)
+
+type IdentityRepository struct {
+	DB *sql.DB
+}
+
+func NewIdentityRepository(db *sql.DB) *IdentityRepository {
+	return &IdentityRepository{DB: db}
+}
+
+// FindUserID mengembalikan sql.ErrNoRows jika subject belum ter-link
+func (r *IdentityRepository) FindUserID(provider, subject string) (string, error) {
+	var userID string
+	err := r.DB.QueryRow(`
+		SELECT user_id FROM user_identities WHERE provider=$1 AND subject=$2
+	`, provider, subject).Scan(&userID)
+	return userID, err
+}
+
+func (r *IdentityRepository) Link(i *models.UserIdentity) error {
+	_, err := r.DB.Exec(`
+		INSERT INTO user_identities (id, user_id, provider, subject, email, created_at, last_login_at)
+		VALUES ($1, $2, $3, $4, $5, NOW(), NOW())
+		ON CONFLICT (provider, subject) DO NOTHING
+	`, i.ID, i.UserID, i.Provider, i.Subject, i.Email)
+	return err
+}
+
+func (r *IdentityRepository) Touch(provider, subject, email string) error {
+	_, err := r.DB.Exec(`
+		UPDATE user_identities SET last_login_at=NOW(), email=$3
+		WHERE provider=$1 AND subject=$2
+	`, provider, subject, email)
+	return err
+}
+
+func (r *IdentityRepository) ListByUser(userID string) ([]models.UserIdentity, error) {
+	rows, err := r.DB.Query(`
+		SELECT id, user_id, provider, subject, email, created_at, last_login_at
+		FROM user_identities WHERE user_id=$1 ORDER BY created_at
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []models.UserIdentity{}
+	for rows.Next() {
+		var i models.UserIdentity
+		if err := rows.Scan(&i.ID, &i.UserID, &i.Provider, &i.Subject, &i.Email, &i.CreatedAt, &i.LastLoginAt); err != nil {
+			return nil, err
+		}
+		list = append(list, i)
+	}
+	return list, rows.Err()
+}
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"database/sql"
+
+	"achievements-uas/app/models"
+)
+
+type ImpersonationRepository struct {
+	DB *sql.DB
+}
+
+func NewImpersonationRepository(db *sql.DB) *ImpersonationRepository {
+	return &ImpersonationRepository{DB: db}
+}
+
+func (r *ImpersonationRepository) Create(s *models.ImpersonationSession) error {
+	_, err := r.DB.Exec(`
+		INSERT INTO impersonation_sessions (id, admin_id, target_user_id, reason, ip_address, started_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, NOW(), $6)
+	`, s.ID, s.AdminID, s.TargetUserID, s.Reason, s.IPAddress, s.ExpiresAt)
+	return err
+}
+
+// End mengembalikan false jika sesi sudah berakhir sebelumnya
+func (r *ImpersonationRepository) End(id string) (bool, error) {
+	res, err := r.DB.Exec(`
+		UPDATE impersonation_sessions SET ended_at = NOW()
+		WHERE id=$1 AND ended_at IS NULL
+	`, id)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+func (r *ImpersonationRepository) RecordRequest(a *models.ImpersonationAudit) error {
+	_, err := r.DB.Exec(`
+		INSERT INTO impersonation_audit
+			(id, impersonation_id, admin_id, target_user_id, method, path, status_code, blocked, ip_address, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NOW())
+	`, a.ID, a.ImpersonationID, a.AdminID, a.TargetUserID, a.Method, a.Path, a.StatusCode, a.Blocked, a.IPAddress)
+	return err
+}
+
+// List sesi impersonation terbaru, opsional difilter per admin / target
+func (r *ImpersonationRepository) List(adminID, targetUserID string, limit int) ([]models.ImpersonationSession, error) {
+	rows, err := r.DB.Query(`
+		SELECT id, admin_id, target_user_id, reason, ip_address, started_at, expires_at, ended_at
+		FROM impersonation_sessions
+		WHERE ($1 = '' OR admin_id::text = $1)
+		  AND ($2 = '' OR target_user_id::text = $2)
+		ORDER BY started_at DESC
+		LIMIT $3
+	`, adminID, targetUserID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []models.ImpersonationSession{}
+	for rows.Next() {
+		var s models.ImpersonationSession
+		if err := rows.Scan(&s.ID, &s.AdminID, &s.TargetUserID, &s.Reason, &s.IPAddress,
+			&s.StartedAt, &s.ExpiresAt, &s.EndedAt); err != nil {
+			return nil, err
+		}
+		list = append(list, s)
+	}
+	return list, rows.Err()
+}
+
+func (r *ImpersonationRepository) ListRequests(impersonationID string) ([]models.ImpersonationAudit, error) {
+	rows, err := r.DB.Query(`
+		SELECT id, impersonation_id, admin_id, target_user_id, method, path, status_code, blocked, ip_address, created_at
+		FROM impersonation_audit
+		WHERE impersonation_id=$1
+		ORDER BY created_at
+	`, impersonationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []models.ImpersonationAudit{}
+	for rows.Next() {
+		var a models.ImpersonationAudit
+		if err := rows.Scan(&a.ID, &a.ImpersonationID, &a.AdminID, &a.TargetUserID, &a.Method,
+			&a.Path, &a.StatusCode, &a.Blocked, &a.IPAddress, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		list = append(list, a)
+	}
+	return list, rows.Err()
+}
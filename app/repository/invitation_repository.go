@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"achievements-uas/app/models"
+	"database/sql"
+)
+
+type InvitationRepository struct {
+	DB *sql.DB
+}
+
+func NewInvitationRepository(db *sql.DB) *InvitationRepository {
+	return &InvitationRepository{DB: db}
+}
+
+// Create membatalkan undangan lama yang masih aktif lalu menyimpan yang baru
+func (r *InvitationRepository) Create(inv *models.UserInvitation, tokenHash string) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`
+		UPDATE user_invitations SET revoked_at=NOW()
+		WHERE user_id=$1 AND accepted_at IS NULL AND revoked_at IS NULL
+	`, inv.UserID); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`
+		INSERT INTO user_invitations (id, user_id, token_hash, created_by, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, NOW(), $5)
+	`, inv.ID, inv.UserID, tokenHash, inv.CreatedBy, inv.ExpiresAt); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// FindValid mengecek token tanpa memakainya (validasi password dulu)
+func (r *InvitationRepository) FindValid(tokenHash string) (string, error) {
+	var userID string
+	err := r.DB.QueryRow(`
+		SELECT user_id FROM user_invitations
+		WHERE token_hash=$1 AND accepted_at IS NULL AND revoked_at IS NULL AND expires_at > NOW()
+	`, tokenHash).Scan(&userID)
+	return userID, err
+}
+
+// Accept memakai token, mengatur password dan mengaktifkan user dalam satu
+// transaksi. sql.ErrNoRows = token tidak valid / sudah dipakai / expired.
+func (r *InvitationRepository) Accept(tokenHash, passwordHash string) (string, error) {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	var userID string
+	if err := tx.QueryRow(`
+		UPDATE user_invitations SET accepted_at=NOW()
+		WHERE token_hash=$1 AND accepted_at IS NULL AND revoked_at IS NULL AND expires_at > NOW()
+		RETURNING user_id
+	`, tokenHash).Scan(&userID); err != nil {
+		return "", err
+	}
+
+	if _, err := tx.Exec(`
+		UPDATE users SET password_hash=$2, is_active=true, updated_at=NOW() WHERE id=$1
+	`, userID, passwordHash); err != nil {
+		return "", err
+	}
+
+	return userID, tx.Commit()
+}
@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"achievements-uas/app/models"
+
+	"github.com/lib/pq"
+)
+
+type LoginAttemptRepository struct {
+	DB *sql.DB
+}
+
+func NewLoginAttemptRepository(db *sql.DB) *LoginAttemptRepository {
+	return &LoginAttemptRepository{DB: db}
+}
+
+//
+// ================= LOGIN ATTEMPTS =================
+//
+
+func (r *LoginAttemptRepository) Record(a *models.LoginAttempt) error {
+	_, err := r.DB.Exec(`
+		INSERT INTO login_attempts (id, identifier, user_id, ip_address, user_agent, success, reason, attempted_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, NOW())
+	`, a.ID, a.Identifier, a.UserID, a.IPAddress, a.UserAgent, a.Success, a.Reason)
+	return err
+}
+
+// IPFailures menghitung login gagal dari satu IP sejak waktu tertentu
+// (percobaan yang ditolak karena throttle / lockout tidak dihitung)
+func (r *LoginAttemptRepository) IPFailures(ip string, since time.Time) (int, *time.Time, error) {
+	var count int
+	var last *time.Time
+	err := r.DB.QueryRow(`
+		SELECT COUNT(*), MAX(attempted_at)
+		FROM login_attempts
+		WHERE ip_address=$1 AND success=false AND attempted_at > $2
+		  AND COALESCE(reason, '') <> ALL($3)
+	`, ip, since, pq.Array(models.LoginRejectedReasons)).Scan(&count, &last)
+	return count, last, err
+}
+
+func (r *LoginAttemptRepository) ListByUser(userID string, limit int) ([]models.LoginAttempt, error) {
+	rows, err := r.DB.Query(`
+		SELECT id, identifier, user_id, ip_address, user_agent, success, reason, attempted_at
+		FROM login_attempts
+		WHERE user_id=$1
+		ORDER BY attempted_at DESC
+		LIMIT $2
+	`, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []models.LoginAttempt{}
+	for rows.Next() {
+		var a models.LoginAttempt
+		if err := rows.Scan(&a.ID, &a.Identifier, &a.UserID, &a.IPAddress,
+			&a.UserAgent, &a.Success, &a.Reason, &a.AttemptedAt); err != nil {
+			return nil, err
+		}
+		list = append(list, a)
+	}
+	return list, rows.Err()
+}
+
+//
+// ================= ACCOUNT LOCKOUT =================
+//
+
+func (r *LoginAttemptRepository) GetLockout(userID string) (*models.AccountLockout, error) {
+	l := &models.AccountLockout{UserID: userID}
+	err := r.DB.QueryRow(`
+		SELECT failed_count, last_failed_at, locked_until
+		FROM account_lockouts WHERE user_id=$1
+	`, userID).Scan(&l.FailedCount, &l.LastFailedAt, &l.LockedUntil)
+	if err == sql.ErrNoRows {
+		return l, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// RegisterFailure menambah counter gagal dan mengunci akun jika melewati threshold
+func (r *LoginAttemptRepository) RegisterFailure(userID string, threshold int, lockFor time.Duration) (*models.AccountLockout, error) {
+	l := &models.AccountLockout{UserID: userID}
+	err := r.DB.QueryRow(`
+		INSERT INTO account_lockouts (user_id, failed_count, last_failed_at, updated_at)
+		VALUES ($1, 1, NOW(), NOW())
+		ON CONFLICT (user_id) DO UPDATE
+		SET failed_count = CASE
+		        WHEN account_lockouts.locked_until <= NOW() THEN 1 -- lockout lama sudah lewat, mulai dari awal
+		        ELSE account_lockouts.failed_count + 1
+		    END,
+		    locked_until = CASE
+		        WHEN account_lockouts.locked_until <= NOW() THEN NULL
+		        ELSE account_lockouts.locked_until
+		    END,
+		    last_failed_at = NOW(),
+		    updated_at = NOW()
+		RETURNING failed_count, last_failed_at, locked_until
+	`, userID).Scan(&l.FailedCount, &l.LastFailedAt, &l.LockedUntil)
+	if err != nil {
+		return nil, err
+	}
+
+	if l.FailedCount >= threshold {
+		until := time.Now().Add(lockFor)
+		if _, err := r.DB.Exec(`
+			UPDATE account_lockouts SET locked_until=$2, updated_at=NOW() WHERE user_id=$1
+		`, userID, until); err != nil {
+			return nil, err
+		}
+		l.LockedUntil = &until
+	}
+	return l, nil
+}
+
+// ResetLockout dipanggil saat login sukses atau di-unlock admin
+func (r *LoginAttemptRepository) ResetLockout(userID string) error {
+	_, err := r.DB.Exec(`DELETE FROM account_lockouts WHERE user_id=$1`, userID)
+	return err
+}
@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"database/sql"
+
+	"achievements-uas/app/models"
+
+	"github.com/google/uuid"
+)
+
+type MFARepository struct {
+	DB *sql.DB
+}
+
+func NewMFARepository(db *sql.DB) *MFARepository {
+	return &MFARepository{DB: db}
+}
+
+// Get mengembalikan sql.ErrNoRows jika user belum pernah setup MFA
+func (r *MFARepository) Get(userID string) (*models.UserMFA, error) {
+	var m models.UserMFA
+	err := r.DB.QueryRow(`
+		SELECT user_id, secret, enabled, last_used_step, created_at, confirmed_at
+		FROM user_mfa WHERE user_id=$1
+	`, userID).Scan(&m.UserID, &m.Secret, &m.Enabled, &m.LastUsedStep, &m.CreatedAt, &m.ConfirmedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// SaveSecret menyimpan secret baru (belum aktif) untuk proses enrollment
+func (r *MFARepository) SaveSecret(userID, secret string) error {
+	_, err := r.DB.Exec(`
+		INSERT INTO user_mfa (user_id, secret, enabled, last_used_step, created_at)
+		VALUES ($1, $2, FALSE, 0, NOW())
+		ON CONFLICT (user_id) DO UPDATE
+		SET secret=EXCLUDED.secret, enabled=FALSE, last_used_step=0, created_at=NOW(), confirmed_at=NULL
+	`, userID, secret)
+	return err
+}
+
+func (r *MFARepository) Enable(userID string, step int64) error {
+	_, err := r.DB.Exec(`
+		UPDATE user_mfa SET enabled=TRUE, confirmed_at=NOW(), last_used_step=$2
+		WHERE user_id=$1
+	`, userID, step)
+	return err
+}
+
+// MarkStepUsed mencegah kode TOTP yang sama dipakai dua kali
+func (r *MFARepository) MarkStepUsed(userID string, step int64) (bool, error) {
+	res, err := r.DB.Exec(`
+		UPDATE user_mfa SET last_used_step=$2
+		WHERE user_id=$1 AND last_used_step < $2
+	`, userID, step)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+// Disable menghapus MFA beserta recovery code (dipakai user atau admin reset)
+func (r *MFARepository) Disable(userID string) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM mfa_recovery_codes WHERE user_id=$1`, userID); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM user_mfa WHERE user_id=$1`, userID); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// ReplaceRecoveryCodes mengganti seluruh recovery code user
+func (r *MFARepository) ReplaceRecoveryCodes(userID string, hashes []string) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM mfa_recovery_codes WHERE user_id=$1`, userID); err != nil {
+		tx.Rollback()
+		return err
+	}
+	for _, h := range hashes {
+		if _, err := tx.Exec(`
+			INSERT INTO mfa_recovery_codes (id, user_id, code_hash, created_at)
+			VALUES ($1, $2, $3, NOW())
+		`, uuid.New().String(), userID, h); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// UseRecoveryCode menandai code terpakai, false jika tidak valid / sudah dipakai
+func (r *MFARepository) UseRecoveryCode(userID, hash string) (bool, error) {
+	res, err := r.DB.Exec(`
+		UPDATE mfa_recovery_codes SET used_at=NOW()
+		WHERE user_id=$1 AND code_hash=$2 AND used_at IS NULL
+	`, userID, hash)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
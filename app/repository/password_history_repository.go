@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"database/sql"
+	"strings"
+)
+
+type PasswordHistoryRepository struct {
+	DB *sql.DB
+}
+
+func NewPasswordHistoryRepository(db *sql.DB) *PasswordHistoryRepository {
+	return &PasswordHistoryRepository{DB: db}
+}
+
+// RecentHashes mengembalikan n hash password terakhir (termasuk password saat ini).
+// Password saat ini juga tersimpan sebagai riwayat terbaru, jadi baris riwayat
+// dengan hash yang sama dilewati agar n benar-benar password yang berbeda.
+func (r *PasswordHistoryRepository) RecentHashes(userID string, n int) ([]string, error) {
+	rows, err := r.DB.Query(`
+		SELECT password_hash FROM (
+			SELECT password_hash, NOW() AS created_at FROM users WHERE id=$1
+			UNION ALL
+			SELECT ph.password_hash, ph.created_at
+			FROM password_history ph
+			JOIN users u ON u.id = ph.user_id
+			WHERE ph.user_id=$1 AND ph.password_hash <> u.password_hash
+		) h
+		ORDER BY created_at DESC
+		LIMIT $2
+	`, userID, n)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var hashes []string
+	for rows.Next() {
+		var h string
+		if err := rows.Scan(&h); err != nil {
+			return nil, err
+		}
+		hashes = append(hashes, h)
+	}
+	return hashes, rows.Err()
+}
+
+// Add mencatat hash baru lalu membuang riwayat di luar batas keep
+func (r *PasswordHistoryRepository) Add(id, userID, hash string, keep int) error {
+	if _, err := r.DB.Exec(`
+		INSERT INTO password_history (id, user_id, password_hash, created_at)
+		VALUES ($1, $2, $3, NOW())
+	`, id, userID, hash); err != nil {
+		return err
+	}
+
+	_, err := r.DB.Exec(`
+		DELETE FROM password_history
+		WHERE user_id=$1 AND id NOT IN (
+			SELECT id FROM password_history WHERE user_id=$1
+			ORDER BY created_at DESC LIMIT $2
+		)
+	`, userID, keep)
+	return err
+}
+
+// GetIdentifiers mengambil data pribadi user yang tidak boleh ada di password
+func (r *PasswordHistoryRepository) GetIdentifiers(userID string) ([]string, error) {
+	var username, email, fullName string
+	var studentID, lecturerID sql.NullString
+
+	err := r.DB.QueryRow(`
+		SELECT u.username, u.email, u.full_name, s.student_id, l.lecturer_id
+		FROM users u
+		LEFT JOIN students s ON s.user_id = u.id
+		LEFT JOIN lecturers l ON l.user_id = u.id
+		WHERE u.id=$1
+	`, userID).Scan(&username, &email, &fullName, &studentID, &lecturerID)
+	if err != nil {
+		return nil, err
+	}
+
+	list := append([]string{username, email}, strings.Fields(fullName)...)
+	if studentID.Valid {
+		list = append(list, studentID.String)
+	}
+	if lecturerID.Valid {
+		list = append(list, lecturerID.String)
+	}
+	return list, nil
+}
@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+)
+
+type PasswordResetRepository struct {
+	DB *sql.DB
+}
+
+func NewPasswordResetRepository(db *sql.DB) *PasswordResetRepository {
+	return &PasswordResetRepository{DB: db}
+}
+
+// Create menyimpan token baru dan membatalkan token lama milik user yang belum terpakai
+func (r *PasswordResetRepository) Create(id, userID, tokenHash string, expiresAt time.Time) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`
+		UPDATE password_reset_tokens SET used_at=NOW()
+		WHERE user_id=$1 AND used_at IS NULL
+	`, userID); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if _, err := tx.Exec(`
+		INSERT INTO password_reset_tokens (id, user_id, token_hash, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, NOW())
+	`, id, userID, tokenHash, expiresAt); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Consume menandai token terpakai secara atomik dan mengembalikan user_id.
+// sql.ErrNoRows berarti token tidak ada, sudah dipakai, atau expired.
+func (r *PasswordResetRepository) Consume(tokenHash string) (string, error) {
+	var userID string
+	err := r.DB.QueryRow(`
+		UPDATE password_reset_tokens SET used_at=NOW()
+		WHERE token_hash=$1 AND used_at IS NULL AND expires_at > NOW()
+		RETURNING user_id
+	`, tokenHash).Scan(&userID)
+	return userID, err
+}
+
+// FindValid mengecek token tanpa memakainya (untuk validasi password sebelum Consume)
+func (r *PasswordResetRepository) FindValid(tokenHash string) (string, error) {
+	var userID string
+	err := r.DB.QueryRow(`
+		SELECT user_id FROM password_reset_tokens
+		WHERE token_hash=$1 AND used_at IS NULL AND expires_at > NOW()
+	`, tokenHash).Scan(&userID)
+	return userID, err
+}
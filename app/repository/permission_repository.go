@@ -43,3 +43,56 @@ func (r *PermissionRepository) FindByID(id string) (*models.Permission, error) {
 	}
 	return &p, nil
 }
+
+// SeedCatalog memastikan semua permission di katalog ada di tabel permissions.
+// Permission yang baru dibuat langsung diberikan ke role bawaan
+// (Admin mendapat semuanya). Mengembalikan jumlah permission baru.
+func (r *PermissionRepository) SeedCatalog(catalog []models.Permission, defaults map[string][]string, adminRole string) (int, error) {
+	grants := map[string][]string{}
+	for role, perms := range defaults {
+		for _, p := range perms {
+			grants[p] = append(grants[p], role)
+		}
+	}
+
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	created := 0
+	for _, p := range catalog {
+		var id string
+		err := tx.QueryRow(`
+			INSERT INTO permissions (id, name, resource, action, description)
+			SELECT gen_random_uuid(), $1, $2, $3, $4
+			WHERE NOT EXISTS (SELECT 1 FROM permissions WHERE name = $1)
+			RETURNING id
+		`, p.Name, p.Resource, p.Action, p.Description).Scan(&id)
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+		created++
+
+		roles := append([]string{adminRole}, grants[p.Name]...)
+		for _, role := range roles {
+			if _, err := tx.Exec(`
+				INSERT INTO role_permissions (role_id, permission_id)
+				SELECT r.id, $2 FROM roles r
+				WHERE r.name = $1
+				  AND NOT EXISTS (
+				      SELECT 1 FROM role_permissions rp
+				      WHERE rp.role_id = r.id AND rp.permission_id = $2
+				  )
+			`, role, id); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	return created, tx.Commit()
+}
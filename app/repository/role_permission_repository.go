@@ -2,6 +2,7 @@ package repository
 
 import (
 	"database/sql"
+	"achievements-uas/app/models"
 )
 
 type RolePermissionRepository struct {
@@ -32,3 +33,73 @@ func (r *RolePermissionRepository) GetPermissionsByRole(roleID string) ([]string
 	}
 	return perms, nil
 }
+
+// GetPermissionDetailsByRole mengembalikan data permission lengkap untuk admin API
+func (r *RolePermissionRepository) GetPermissionDetailsByRole(roleID string) ([]models.Permission, error) {
+	rows, err := r.DB.Query(`
+		SELECT p.id, p.name, p.resource, p.action, p.description
+		FROM permissions p
+		JOIN role_permissions rp ON rp.permission_id = p.id
+		WHERE rp.role_id = $1
+		ORDER BY p.name
+	`, roleID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []models.Permission{}
+	for rows.Next() {
+		var p models.Permission
+		if err := rows.Scan(&p.ID, &p.Name, &p.Resource, &p.Action, &p.Description); err != nil {
+			return nil, err
+		}
+		list = append(list, p)
+	}
+	return list, rows.Err()
+}
+
+// Attach idempotent: mengembalikan false jika mapping sudah ada
+func (r *RolePermissionRepository) Attach(rp models.RolePermission) (bool, error) {
+	res, err := r.DB.Exec(`
+		INSERT INTO role_permissions (role_id, permission_id)
+		SELECT $1, $2
+		WHERE NOT EXISTS (
+			SELECT 1 FROM role_permissions WHERE role_id=$1 AND permission_id=$2
+		)
+	`, rp.RoleID, rp.PermissionID)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// Detach mengembalikan false jika mapping memang tidak ada
+func (r *RolePermissionRepository) Detach(rp models.RolePermission) (bool, error) {
+	res, err := r.DB.Exec(`
+		DELETE FROM role_permissions WHERE role_id=$1 AND permission_id=$2
+	`, rp.RoleID, rp.PermissionID)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// PermissionsWithVersion dipakai cache permission (utils.PermissionSource).
+// Versi 0 berarti mapping role belum pernah berubah sejak migrasi.
+func (r *RolePermissionRepository) PermissionsWithVersion(roleID string) ([]string, int64, error) {
+	var version int64
+	if err := r.DB.QueryRow(`
+		SELECT COALESCE((SELECT version FROM role_permission_versions WHERE role_id = $1), 0)
+	`, roleID).Scan(&version); err != nil {
+		return nil, 0, err
+	}
+
+	perms, err := r.GetPermissionsByRole(roleID)
+	if err != nil {
+		return nil, 0, err
+	}
+	return perms, version, nil
+}
@@ -53,3 +53,72 @@ func (r *RoleRepository) GetNameByID(id string) (string, error) {
 	}
 	return name, nil
 }
+
+func (r *RoleRepository) FindByName(name string) (*models.Role, error) {
+	row := r.DB.QueryRow(`
+		SELECT id, name, description, created_at FROM roles WHERE LOWER(name)=LOWER($1)
+	`, name)
+
+	var role models.Role
+	if err := row.Scan(&role.ID, &role.Name, &role.Description, &role.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+func (r *RoleRepository) Create(role *models.Role) error {
+	_, err := r.DB.Exec(`
+		INSERT INTO roles (id, name, description, created_at)
+		VALUES ($1, $2, $3, $4)
+	`, role.ID, role.Name, role.Description, role.CreatedAt)
+	return err
+}
+
+func (r *RoleRepository) Update(role *models.Role) error {
+	res, err := r.DB.Exec(`
+		UPDATE roles SET name=$2, description=$3 WHERE id=$1
+	`, role.ID, role.Name, role.Description)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// CountUsers dipakai untuk mencegah penghapusan role yang masih dipakai
+func (r *RoleRepository) CountUsers(id string) (int, error) {
+	var total int
+	err := r.DB.QueryRow(`SELECT COUNT(*) FROM users WHERE role_id=$1`, id).Scan(&total)
+	return total, err
+}
+
+// Delete menghapus role beserta mapping permission-nya. Pengecekan user
+// diulang di dalam transaksi agar tidak kebobolan oleh assign yang bersamaan.
+func (r *RoleRepository) Delete(id string) (bool, error) {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	var inUse bool
+	if err := tx.QueryRow(`
+		SELECT EXISTS (SELECT 1 FROM users WHERE role_id=$1)
+		FROM roles WHERE id=$1 FOR UPDATE
+	`, id).Scan(&inUse); err != nil {
+		return false, err
+	}
+	if inUse {
+		return false, nil
+	}
+
+	if _, err := tx.Exec(`DELETE FROM role_permissions WHERE role_id=$1`, id); err != nil {
+		return false, err
+	}
+	if _, err := tx.Exec(`DELETE FROM roles WHERE id=$1`, id); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
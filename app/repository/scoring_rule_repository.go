@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"database/sql"
+
+	"achievements-uas/app/models"
+)
+
+type ScoringRuleRepository struct {
+	DB *sql.DB
+}
+
+func NewScoringRuleRepository(db *sql.DB) *ScoringRuleRepository {
+	return &ScoringRuleRepository{DB: db}
+}
+
+const scoringRuleColumns = `id, name, achievement_type, competition_level, rank, medal_type,
+	publication_type, position, points, priority, is_active, created_at, updated_at`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanScoringRule(row rowScanner) (*models.ScoringRule, error) {
+	var r models.ScoringRule
+	var rank sql.NullInt64
+	if err := row.Scan(&r.ID, &r.Name, &r.AchievementType, &r.CompetitionLevel, &rank, &r.MedalType,
+		&r.PublicationType, &r.Position, &r.Points, &r.Priority, &r.IsActive, &r.CreatedAt, &r.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if rank.Valid {
+		v := int(rank.Int64)
+		r.Rank = &v
+	}
+	return &r, nil
+}
+
+// FindAll; activeOnly=true untuk engine scoring
+func (r *ScoringRuleRepository) FindAll(activeOnly bool) ([]models.ScoringRule, error) {
+	rows, err := r.DB.Query(`
+		SELECT `+scoringRuleColumns+` FROM scoring_rules
+		WHERE is_active OR NOT $1
+		ORDER BY priority DESC, points DESC, name
+	`, activeOnly)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []models.ScoringRule{}
+	for rows.Next() {
+		rule, err := scanScoringRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *rule)
+	}
+	return list, rows.Err()
+}
+
+func (r *ScoringRuleRepository) FindByID(id string) (*models.ScoringRule, error) {
+	return scanScoringRule(r.DB.QueryRow(`SELECT `+scoringRuleColumns+` FROM scoring_rules WHERE id=$1`, id))
+}
+
+func (r *ScoringRuleRepository) Create(rule *models.ScoringRule) error {
+	_, err := r.DB.Exec(`
+		INSERT INTO scoring_rules (id, name, achievement_type, competition_level, rank, medal_type,
+			publication_type, position, points, priority, is_active, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, NOW(), NOW())
+	`, rule.ID, rule.Name, rule.AchievementType, rule.CompetitionLevel, rule.Rank, rule.MedalType,
+		rule.PublicationType, rule.Position, rule.Points, rule.Priority, rule.IsActive)
+	return err
+}
+
+func (r *ScoringRuleRepository) Update(rule *models.ScoringRule) (bool, error) {
+	res, err := r.DB.Exec(`
+		UPDATE scoring_rules SET name=$2, achievement_type=$3, competition_level=$4, rank=$5, medal_type=$6,
+			publication_type=$7, position=$8, points=$9, priority=$10, is_active=$11, updated_at=NOW()
+		WHERE id=$1
+	`, rule.ID, rule.Name, rule.AchievementType, rule.CompetitionLevel, rule.Rank, rule.MedalType,
+		rule.PublicationType, rule.Position, rule.Points, rule.Priority, rule.IsActive)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+func (r *ScoringRuleRepository) Delete(id string) (bool, error) {
+	res, err := r.DB.Exec(`DELETE FROM scoring_rules WHERE id=$1`, id)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"achievements-uas/app/models"
+)
+
+type SessionRepository struct {
+	DB *sql.DB
+}
+
+func NewSessionRepository(db *sql.DB) *SessionRepository {
+	return &SessionRepository{DB: db}
+}
+
+const sessionColumns = `id, user_id, refresh_jti, user_agent, ip_address,
+	created_at, last_used_at, expires_at, revoked_at, revoke_reason`
+
+func scanSession(row interface{ Scan(...interface{}) error }) (*models.Session, error) {
+	var s models.Session
+	if err := row.Scan(
+		&s.ID, &s.UserID, &s.RefreshJTI, &s.UserAgent, &s.IPAddress,
+		&s.CreatedAt, &s.LastUsedAt, &s.ExpiresAt, &s.RevokedAt, &s.RevokeReason,
+	); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// CREATE SESSION (saat login)
+func (r *SessionRepository) Create(s *models.Session) error {
+	_, err := r.DB.Exec(`
+		INSERT INTO sessions (id, user_id, refresh_jti, user_agent, ip_address, created_at, last_used_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, NOW(), NOW(), $6)
+	`, s.ID, s.UserID, s.RefreshJTI, s.UserAgent, s.IPAddress, s.ExpiresAt)
+	return err
+}
+
+func (r *SessionRepository) FindByID(id string) (*models.Session, error) {
+	row := r.DB.QueryRow(`SELECT `+sessionColumns+` FROM sessions WHERE id=$1`, id)
+	return scanSession(row)
+}
+
+// Rotate mengganti refresh_jti secara atomik (compare-and-swap).
+// Return false jika jti lama sudah tidak cocok → token lama dipakai ulang.
+func (r *SessionRepository) Rotate(id, oldJTI, newJTI string, expiresAt time.Time) (bool, error) {
+	res, err := r.DB.Exec(`
+		UPDATE sessions
+		SET refresh_jti=$3, expires_at=$4, last_used_at=NOW()
+		WHERE id=$1 AND refresh_jti=$2 AND revoked_at IS NULL
+	`, id, oldJTI, newJTI, expiresAt)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
+// REVOKE SATU SESSION
+func (r *SessionRepository) Revoke(id, reason string) error {
+	_, err := r.DB.Exec(`
+		UPDATE sessions SET revoked_at=NOW(), revoke_reason=$2
+		WHERE id=$1 AND revoked_at IS NULL
+	`, id, reason)
+	return err
+}
+
+// LIST SESSION AKTIF MILIK USER
+func (r *SessionRepository) ListActiveByUser(userID string) ([]models.Session, error) {
+	rows, err := r.DB.Query(`
+		SELECT `+sessionColumns+`
+		FROM sessions
+		WHERE user_id=$1 AND revoked_at IS NULL AND expires_at > NOW()
+		ORDER BY last_used_at DESC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []models.Session{}
+	for rows.Next() {
+		s, err := scanSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *s)
+	}
+	return list, rows.Err()
+}
+
+// RevokeAllByUser me-revoke semua session aktif user kecuali exceptID (boleh kosong).
+// Mengembalikan ID session yang di-revoke.
+func (r *SessionRepository) RevokeAllByUser(userID, exceptID, reason string) ([]string, error) {
+	rows, err := r.DB.Query(`
+		UPDATE sessions SET revoked_at=NOW(), revoke_reason=$3
+		WHERE user_id=$1 AND revoked_at IS NULL AND ($2 = '' OR id::text <> $2)
+		RETURNING id
+	`, userID, exceptID, reason)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
@@ -0,0 +1,44 @@
+package database
+
+import (
+	"embed"
+	"fmt"
+	"log"
+	"sort"
+)
+
+// File SQL di folder migrations dijalankan berurutan saat startup.
+// Setiap file wajib idempotent (CREATE ... IF NOT EXISTS) agar aman dijalankan ulang.
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+func RunMigrations() error {
+	if Postgres == nil {
+		return fmt.Errorf("postgres not connected")
+	}
+
+	entries, err := migrationFiles.ReadDir("migrations")
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		sqlBytes, err := migrationFiles.ReadFile("migrations/" + name)
+		if err != nil {
+			return err
+		}
+		if _, err := Postgres.Exec(string(sqlBytes)); err != nil {
+			return fmt.Errorf("migration %s: %w", name, err)
+		}
+	}
+
+	log.Printf("Postgres migrations applied (%d files)", len(names))
+	return nil
+}
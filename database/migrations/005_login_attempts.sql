@@ -0,0 +1,23 @@
+-- Catatan setiap percobaan login (dasar throttling per IP dan audit).
+CREATE TABLE IF NOT EXISTS login_attempts (
+    id           UUID PRIMARY KEY,
+    identifier   TEXT NOT NULL,
+    user_id      UUID REFERENCES users(id) ON DELETE SET NULL,
+    ip_address   TEXT NOT NULL DEFAULT '',
+    user_agent   TEXT NOT NULL DEFAULT '',
+    success      BOOLEAN NOT NULL,
+    reason       TEXT NOT NULL DEFAULT '',
+    attempted_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_login_attempts_ip ON login_attempts (ip_address, attempted_at DESC);
+CREATE INDEX IF NOT EXISTS idx_login_attempts_user ON login_attempts (user_id, attempted_at DESC);
+
+-- Status gagal berturut-turut & lockout per akun.
+CREATE TABLE IF NOT EXISTS account_lockouts (
+    user_id        UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    failed_count   INT NOT NULL DEFAULT 0,
+    last_failed_at TIMESTAMPTZ,
+    locked_until   TIMESTAMPTZ,
+    updated_at     TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
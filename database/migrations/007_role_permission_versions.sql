@@ -0,0 +1,46 @@
+-- Versi mapping permission per role. Setiap perubahan role_permissions
+-- (lewat API maupun SQL manual) menaikkan versi sehingga access token
+-- dengan klaim "pv" lama terdeteksi sebagai stale.
+CREATE TABLE IF NOT EXISTS role_permission_versions (
+    role_id    UUID PRIMARY KEY REFERENCES roles(id) ON DELETE CASCADE,
+    version    BIGINT NOT NULL DEFAULT 1,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE OR REPLACE FUNCTION bump_role_permission_version() RETURNS TRIGGER AS $$
+DECLARE
+    rid UUID;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        rid := OLD.role_id;
+    ELSE
+        rid := NEW.role_id;
+    END IF;
+
+    -- role yang sedang dihapus tidak perlu versi baru
+    IF NOT EXISTS (SELECT 1 FROM roles WHERE id = rid) THEN
+        RETURN NULL;
+    END IF;
+
+    INSERT INTO role_permission_versions (role_id, version, updated_at)
+    VALUES (rid, 1, NOW())
+    ON CONFLICT (role_id) DO UPDATE
+        SET version = role_permission_versions.version + 1,
+            updated_at = NOW();
+
+    IF TG_OP = 'UPDATE' AND OLD.role_id <> NEW.role_id THEN
+        INSERT INTO role_permission_versions (role_id, version, updated_at)
+        VALUES (OLD.role_id, 1, NOW())
+        ON CONFLICT (role_id) DO UPDATE
+            SET version = role_permission_versions.version + 1,
+                updated_at = NOW();
+    END IF;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_role_permissions_version ON role_permissions;
+CREATE TRIGGER trg_role_permissions_version
+    AFTER INSERT OR UPDATE OR DELETE ON role_permissions
+    FOR EACH ROW EXECUTE FUNCTION bump_role_permission_version();
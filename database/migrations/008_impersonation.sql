@@ -0,0 +1,30 @@
+-- Sesi "login sebagai" oleh admin. id = klaim sid token impersonation.
+CREATE TABLE IF NOT EXISTS impersonation_sessions (
+    id             UUID PRIMARY KEY,
+    admin_id       UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    target_user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    reason         TEXT NOT NULL DEFAULT '',
+    ip_address     TEXT NOT NULL DEFAULT '',
+    started_at     TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at     TIMESTAMPTZ NOT NULL,
+    ended_at       TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_impersonation_admin ON impersonation_sessions (admin_id, started_at DESC);
+CREATE INDEX IF NOT EXISTS idx_impersonation_target ON impersonation_sessions (target_user_id, started_at DESC);
+
+-- Setiap request yang dilakukan dengan token impersonation (termasuk yang diblokir).
+CREATE TABLE IF NOT EXISTS impersonation_audit (
+    id               UUID PRIMARY KEY,
+    impersonation_id UUID NOT NULL REFERENCES impersonation_sessions(id) ON DELETE CASCADE,
+    admin_id         UUID NOT NULL,
+    target_user_id   UUID NOT NULL,
+    method           TEXT NOT NULL,
+    path             TEXT NOT NULL,
+    status_code      INT NOT NULL,
+    blocked          BOOLEAN NOT NULL DEFAULT FALSE,
+    ip_address       TEXT NOT NULL DEFAULT '',
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_impersonation_audit_session ON impersonation_audit (impersonation_id, created_at);
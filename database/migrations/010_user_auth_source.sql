@@ -0,0 +1,3 @@
+-- Backend autentikasi per user: '' = ikut aturan role (AUTH_SOURCE_BY_ROLE),
+-- 'local' = bcrypt di Postgres, 'ldap' = bind ke direktori fakultas.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS auth_source TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,16 @@
+-- API key untuk integrasi antar sistem (dashboard fakultas, SKPI).
+-- Key hanya disimpan sebagai hash SHA-256; prefix dipakai untuk identifikasi di UI.
+CREATE TABLE IF NOT EXISTS api_keys (
+    id           UUID PRIMARY KEY,
+    name         TEXT NOT NULL,
+    prefix       TEXT NOT NULL,
+    key_hash     TEXT NOT NULL UNIQUE,
+    scopes       TEXT[] NOT NULL DEFAULT '{}',
+    allowed_ips  TEXT[] NOT NULL DEFAULT '{}',
+    created_by   UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at   TIMESTAMPTZ,
+    last_used_at TIMESTAMPTZ,
+    last_used_ip TEXT NOT NULL DEFAULT '',
+    revoked_at   TIMESTAMPTZ
+);
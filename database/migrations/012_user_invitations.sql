@@ -0,0 +1,13 @@
+-- Undangan aktivasi akun: user dibuat nonaktif, lalu mengatur password sendiri.
+CREATE TABLE IF NOT EXISTS user_invitations (
+    id          UUID PRIMARY KEY,
+    user_id     UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    token_hash  TEXT NOT NULL UNIQUE,
+    created_by  UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at  TIMESTAMPTZ NOT NULL,
+    accepted_at TIMESTAMPTZ,
+    revoked_at  TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_invitations_user ON user_invitations (user_id, created_at DESC);
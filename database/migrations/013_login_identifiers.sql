@@ -0,0 +1,4 @@
+-- NIM dan NIDN dipakai sebagai identifier login, jadi harus unik.
+-- Gagal jika data lama berisi duplikat: bersihkan dulu sebelum deploy.
+CREATE UNIQUE INDEX IF NOT EXISTS uq_students_student_id ON students (student_id);
+CREATE UNIQUE INDEX IF NOT EXISTS uq_lecturers_lecturer_id ON lecturers (lecturer_id);
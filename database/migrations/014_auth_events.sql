@@ -0,0 +1,35 @@
+-- Log keamanan autentikasi (login, refresh, logout, ganti password, lockout).
+-- Append-only: UPDATE / DELETE / TRUNCATE ditolak oleh trigger.
+-- user_id sengaja tanpa foreign key agar event tetap ada walau user dihapus.
+CREATE TABLE IF NOT EXISTS auth_events (
+    id          UUID PRIMARY KEY,
+    event_type  TEXT NOT NULL,
+    success     BOOLEAN NOT NULL,
+    user_id     UUID,
+    identifier  TEXT NOT NULL DEFAULT '',
+    session_id  UUID,
+    ip_address  TEXT NOT NULL DEFAULT '',
+    user_agent  TEXT NOT NULL DEFAULT '',
+    detail      TEXT NOT NULL DEFAULT '',
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_auth_events_created ON auth_events (created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_auth_events_user ON auth_events (user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_auth_events_type ON auth_events (event_type, created_at DESC);
+
+CREATE OR REPLACE FUNCTION auth_events_append_only() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'auth_events is append-only (% not allowed)', TG_OP;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_auth_events_append_only ON auth_events;
+CREATE TRIGGER trg_auth_events_append_only
+    BEFORE UPDATE OR DELETE ON auth_events
+    FOR EACH ROW EXECUTE FUNCTION auth_events_append_only();
+
+DROP TRIGGER IF EXISTS trg_auth_events_no_truncate ON auth_events;
+CREATE TRIGGER trg_auth_events_no_truncate
+    BEFORE TRUNCATE ON auth_events
+    FOR EACH STATEMENT EXECUTE FUNCTION auth_events_append_only();
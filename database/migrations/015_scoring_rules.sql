@@ -0,0 +1,22 @@
+-- Rulebook poin prestasi. Kolom kriteria NULL = tidak dipakai (wildcard).
+-- Rule yang cocok dengan kriteria terbanyak menang, lalu priority tertinggi.
+CREATE TABLE IF NOT EXISTS scoring_rules (
+    id                UUID PRIMARY KEY,
+    name              TEXT NOT NULL,
+    achievement_type  TEXT,
+    competition_level TEXT,
+    rank              INT,
+    medal_type        TEXT,
+    publication_type  TEXT,
+    position          TEXT,
+    points            INT NOT NULL CHECK (points >= 0),
+    priority          INT NOT NULL DEFAULT 0,
+    is_active         BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at        TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at        TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Poin & rule yang dipakai saat prestasi diverifikasi
+ALTER TABLE achievement_references ADD COLUMN IF NOT EXISTS points INT NOT NULL DEFAULT 0;
+ALTER TABLE achievement_references ADD COLUMN IF NOT EXISTS scoring_rule_id UUID REFERENCES scoring_rules(id) ON DELETE SET NULL;
+ALTER TABLE achievement_references ADD COLUMN IF NOT EXISTS scored_at TIMESTAMPTZ;
@@ -0,0 +1,40 @@
+-- Status baru 'revision_requested'. Kolom status bisa berupa enum atau
+-- TEXT/VARCHAR dengan CHECK constraint; keduanya ditangani. Constraint yang
+-- sudah mengenal nilai ini (termasuk yang dipasang migrasi berikutnya)
+-- dibiarkan, sehingga file ini aman dijalankan ulang setiap startup.
+DO $$
+DECLARE
+    col_type TEXT;
+    con      RECORD;
+    replaced BOOLEAN := FALSE;
+BEGIN
+    SELECT c.udt_name INTO col_type
+    FROM information_schema.columns c
+    WHERE c.table_schema = current_schema()
+      AND c.table_name = 'achievement_references'
+      AND c.column_name = 'status';
+
+    IF EXISTS (SELECT 1 FROM pg_type WHERE typname = col_type AND typtype = 'e') THEN
+        EXECUTE format('ALTER TYPE %I ADD VALUE IF NOT EXISTS %L', col_type, 'revision_requested');
+        RETURN;
+    END IF;
+
+    FOR con IN
+        SELECT conname, pg_get_constraintdef(oid) AS def
+        FROM pg_constraint
+        WHERE conrelid = 'achievement_references'::regclass
+          AND contype = 'c'
+          AND pg_get_constraintdef(oid) LIKE '%status%'
+    LOOP
+        IF con.def NOT LIKE '%revision_requested%' THEN
+            EXECUTE format('ALTER TABLE achievement_references DROP CONSTRAINT %I', con.conname);
+            replaced := TRUE;
+        END IF;
+    END LOOP;
+
+    IF replaced THEN
+        ALTER TABLE achievement_references ADD CONSTRAINT achievement_references_status_check
+            CHECK (status IN ('draft', 'submitted', 'revision_requested', 'verified', 'rejected', 'deleted'));
+    END IF;
+END
+$$;
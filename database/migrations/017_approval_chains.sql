@@ -0,0 +1,80 @@
+-- Rantai persetujuan bertingkat per jenis / tingkat prestasi. Kolom kriteria
+-- NULL = wildcard; chain paling spesifik menang, lalu priority tertinggi.
+-- Prestasi tanpa chain yang cocok cukup disetujui Dosen Wali (1 tahap).
+CREATE TABLE IF NOT EXISTS approval_chains (
+    id                UUID PRIMARY KEY,
+    name              TEXT NOT NULL,
+    achievement_type  TEXT,
+    competition_level TEXT,
+    priority          INT NOT NULL DEFAULT 0,
+    is_active         BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at        TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at        TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- approver_type 'advisor' = dosen wali mahasiswa, 'role' = user dengan role_id
+CREATE TABLE IF NOT EXISTS approval_chain_stages (
+    chain_id      UUID NOT NULL REFERENCES approval_chains(id) ON DELETE CASCADE,
+    stage_order   INT NOT NULL CHECK (stage_order > 0),
+    name          TEXT NOT NULL,
+    approver_type TEXT NOT NULL CHECK (approver_type IN ('advisor', 'role')),
+    role_id       UUID REFERENCES roles(id) ON DELETE RESTRICT,
+    PRIMARY KEY (chain_id, stage_order),
+    CHECK (approver_type <> 'role' OR role_id IS NOT NULL)
+);
+
+-- Tahap yang sudah disetujui untuk prestasi yang sedang diproses
+ALTER TABLE achievement_references ADD COLUMN IF NOT EXISTS approval_chain_id UUID REFERENCES approval_chains(id) ON DELETE SET NULL;
+ALTER TABLE achievement_references ADD COLUMN IF NOT EXISTS approval_stage INT NOT NULL DEFAULT 0;
+
+-- Jejak setiap persetujuan tahap (tidak dihapus saat prestasi diajukan ulang)
+CREATE TABLE IF NOT EXISTS achievement_approvals (
+    id                   UUID PRIMARY KEY,
+    mongo_achievement_id TEXT NOT NULL,
+    chain_id             UUID REFERENCES approval_chains(id) ON DELETE SET NULL,
+    stage_order          INT NOT NULL,
+    stage_name           TEXT NOT NULL,
+    approved_by          UUID REFERENCES users(id) ON DELETE SET NULL,
+    approved_at          TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_achievement_approvals_mongo ON achievement_approvals (mongo_achievement_id, approved_at);
+
+-- Status baru 'partially_approved' (sebagian tahap sudah menyetujui).
+-- Sama seperti 016: enum ditambah nilainya, CHECK constraint diganti.
+DO $$
+DECLARE
+    col_type TEXT;
+    con      RECORD;
+    replaced BOOLEAN := FALSE;
+BEGIN
+    SELECT c.udt_name INTO col_type
+    FROM information_schema.columns c
+    WHERE c.table_schema = current_schema()
+      AND c.table_name = 'achievement_references'
+      AND c.column_name = 'status';
+
+    IF EXISTS (SELECT 1 FROM pg_type WHERE typname = col_type AND typtype = 'e') THEN
+        EXECUTE format('ALTER TYPE %I ADD VALUE IF NOT EXISTS %L', col_type, 'partially_approved');
+        RETURN;
+    END IF;
+
+    FOR con IN
+        SELECT conname, pg_get_constraintdef(oid) AS def
+        FROM pg_constraint
+        WHERE conrelid = 'achievement_references'::regclass
+          AND contype = 'c'
+          AND pg_get_constraintdef(oid) LIKE '%status%'
+    LOOP
+        IF con.def NOT LIKE '%partially_approved%' THEN
+            EXECUTE format('ALTER TABLE achievement_references DROP CONSTRAINT %I', con.conname);
+            replaced := TRUE;
+        END IF;
+    END LOOP;
+
+    IF replaced THEN
+        ALTER TABLE achievement_references ADD CONSTRAINT achievement_references_status_check
+            CHECK (status IN ('draft', 'submitted', 'revision_requested', 'partially_approved',
+                              'verified', 'rejected', 'deleted'));
+    END IF;
+END
+$$;
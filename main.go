@@ -1,12 +1,17 @@
 package main
 
 import (
+	"context"
 	"log"
+	"os"
+	"time"
 
+	"achievements-uas/app/models"
 	"achievements-uas/database"
 	"achievements-uas/app/repository"
 	"achievements-uas/routes"
 	"achievements-uas/services"
+	"achievements-uas/utils"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/joho/godotenv"
@@ -31,23 +36,110 @@ func main() {
 		log.Fatal("[FATAL] MongoDB error:", err)
 	}
 
+	if err := database.RunMigrations(); err != nil {
+		log.Fatal("[FATAL] Migration error:", err)
+	}
+
+	// Katalog permission (resource:action) wajib ada sebelum route dipakai
+	permissionRepo := repository.NewPermissionRepository(database.Postgres)
+	seeded, err := permissionRepo.SeedCatalog(models.PermissionCatalog, models.DefaultRolePermissions, models.AdminRoleName)
+	if err != nil {
+		log.Fatal("[FATAL] Permission seed error:", err)
+	}
+	if seeded > 0 {
+		log.Printf("%d new permissions seeded", seeded)
+	}
+
+	// ===============================
+	// TOKEN REVOCATION STORE
+	// ===============================
+	// REVOCATION_STORE: postgres (default) | redis | memory
+	switch os.Getenv("REVOCATION_STORE") {
+	case "redis":
+		store, err := utils.NewRedisRevocationStore(os.Getenv("REDIS_URL"))
+		if err != nil {
+			log.Fatal("[FATAL] Redis error:", err)
+		}
+		utils.SetRevocationStore(store)
+	case "memory":
+		log.Println("[WARN] using in-memory revocation store, logout is not shared between instances")
+	default:
+		utils.SetRevocationStore(repository.NewRevokedTokenRepository(database.Postgres))
+	}
+
+	sweepInterval, err := time.ParseDuration(os.Getenv("REVOCATION_SWEEP_INTERVAL"))
+	if err != nil {
+		sweepInterval = 10 * time.Minute
+	}
+	utils.StartRevocationSweeper(context.Background(), sweepInterval)
+
+	// ===============================
+	// JWT SIGNING KEYS
+	// ===============================
+	if err := utils.InitSigningKeys(); err != nil {
+		log.Fatal("[FATAL] JWT key error:", err)
+	}
+
+	rotateInterval, err := time.ParseDuration(os.Getenv("JWT_KEY_ROTATE_INTERVAL"))
+	if err != nil {
+		rotateInterval = 24 * time.Hour
+	}
+	utils.StartKeyRotation(context.Background(), rotateInterval)
+
 	// ===============================
 	// INIT REPOSITORIES
 	// ===============================
 	adminRepo := repository.NewAdminRepository(database.Postgres)
 	roleRepo := repository.NewRoleRepository(database.Postgres)
 	rolePermRepo := repository.NewRolePermissionRepository(database.Postgres)
+	utils.SetPermissionSource(rolePermRepo)
 	authRepo := repository.NewAuthRepository(database.Postgres)
+	sessionRepo := repository.NewSessionRepository(database.Postgres)
+	resetRepo := repository.NewPasswordResetRepository(database.Postgres)
+	passwordHistoryRepo := repository.NewPasswordHistoryRepository(database.Postgres)
+	loginAttemptRepo := repository.NewLoginAttemptRepository(database.Postgres)
+	mfaRepo := repository.NewMFARepository(database.Postgres)
+	impersonationRepo := repository.NewImpersonationRepository(database.Postgres)
+	identityRepo := repository.NewIdentityRepository(database.Postgres)
+	apiKeyRepo := repository.NewAPIKeyRepository(database.Postgres)
+	invitationRepo := repository.NewInvitationRepository(database.Postgres)
+	authEventRepo := repository.NewAuthEventRepository(database.Postgres)
+	scoringRuleRepo := repository.NewScoringRuleRepository(database.Postgres)
+	approvalChainRepo := repository.NewApprovalChainRepository(database.Postgres)
+	utils.SetAPIKeyStore(apiKeyRepo)
 
 	studentRepo := repository.NewStudentRepository(database.Postgres)
 
 	achPgRepo := repository.NewAchievementPostgresRepository(database.Postgres)
 	achMongoRepo := repository.NewAchievementMongoRepository(database.MongoDB)
+	achCommentRepo := repository.NewAchievementCommentRepository(database.MongoDB)
 
 	// ===============================
 	// INIT SERVICES
 	// ===============================
-	authService := services.NewAuthService(authRepo, roleRepo, rolePermRepo)
+	if err := utils.InitPasswordPolicy(); err != nil {
+		log.Fatal("[FATAL] Password policy error:", err)
+	}
+
+	mailer := utils.NewMailerFromEnv()
+	passwordService := services.NewPasswordService(passwordHistoryRepo)
+
+	authService := services.NewAuthService(
+		authRepo,
+		roleRepo,
+		rolePermRepo,
+		sessionRepo,
+		resetRepo,
+		mailer,
+		passwordService,
+		loginAttemptRepo,
+		mfaRepo,
+		identityRepo,
+		authEventRepo,
+		utils.NewOIDCProvider(utils.OIDCConfigFromEnv()),
+	)
+
+	invitationService := services.NewInvitationService(invitationRepo, adminRepo, mailer, passwordService)
 
 	adminService := services.NewAdminService(
 		adminRepo,
@@ -55,15 +147,30 @@ func main() {
 		rolePermRepo,
 		achPgRepo,
 		achMongoRepo,
+		passwordService,
+		invitationService,
 	)
 
 	achievementService := &services.AchievementService{
-		MongoRepo:   achMongoRepo,
-		PgRepo:      achPgRepo,
-		AdminRepo:   adminRepo,
-
+		MongoRepo:    achMongoRepo,
+		PgRepo:       achPgRepo,
+		AdminRepo:    adminRepo,
+		ScoringRepo:  scoringRuleRepo,
+		CommentRepo:  achCommentRepo,
+		Mailer:       mailer,
+		ApprovalRepo: approvalChainRepo,
 	}
 
+	roleService := services.NewRoleAdminService(roleRepo, permissionRepo, rolePermRepo)
+
+	impersonationService := services.NewImpersonationService(impersonationRepo, authRepo, roleRepo)
+
+	apiKeyService := services.NewAPIKeyService(apiKeyRepo, permissionRepo)
+
+	scoringService := services.NewScoringService(scoringRuleRepo, achMongoRepo, achPgRepo)
+
+	approvalChainService := services.NewApprovalChainService(approvalChainRepo, roleRepo)
+
 	reportService := &services.ReportService{
 		MongoRepo:   achMongoRepo,
 		StudentRepo: studentRepo,
@@ -82,6 +189,12 @@ func main() {
 		adminService,
 		achievementService,
 		reportService, // ← WAJIB
+		roleService,
+		impersonationService,
+		apiKeyService,
+		invitationService,
+		scoringService,
+		approvalChainService,
 	)
 
 	// ===============================
@@ -0,0 +1,107 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// =====================================================
+// COOKIE AUTH MODE (untuk SPA)
+// =====================================================
+// AUTH_COOKIE_MODE=true: login/refresh menyimpan token di cookie HttpOnly
+// sehingga tidak perlu localStorage. Request yang mengubah data wajib
+// mengirim header X-CSRF-Token yang sama dengan cookie CSRF (double submit).
+
+const (
+	AccessTokenCookie  = "uas_access_token"
+	RefreshTokenCookie = "uas_refresh_token"
+	CSRFCookie         = "uas_csrf_token"
+	CSRFHeader         = "X-CSRF-Token"
+)
+
+type CookieAuthConfig struct {
+	Enabled  bool
+	Domain   string
+	Secure   bool
+	SameSite string
+	// refresh token hanya dikirim ke endpoint auth, bukan ke semua request API
+	RefreshPath string
+}
+
+func CookieAuthConfigFromEnv() CookieAuthConfig {
+	cfg := CookieAuthConfig{
+		Enabled:     os.Getenv("AUTH_COOKIE_MODE") == "true",
+		Domain:      os.Getenv("AUTH_COOKIE_DOMAIN"),
+		Secure:      os.Getenv("AUTH_COOKIE_SECURE") != "false",
+		SameSite:    fiber.CookieSameSiteStrictMode,
+		RefreshPath: "/api/v1/auth",
+	}
+	switch strings.ToLower(os.Getenv("AUTH_COOKIE_SAMESITE")) {
+	case "lax":
+		cfg.SameSite = fiber.CookieSameSiteLaxMode
+	case "none":
+		// SameSite=None hanya diterima browser jika Secure
+		cfg.SameSite = fiber.CookieSameSiteNoneMode
+		cfg.Secure = true
+	}
+	return cfg
+}
+
+func (cfg CookieAuthConfig) cookie(name, value, path string, expires time.Time, httpOnly bool) *fiber.Cookie {
+	return &fiber.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     path,
+		Domain:   cfg.Domain,
+		Expires:  expires,
+		Secure:   cfg.Secure,
+		HTTPOnly: httpOnly,
+		SameSite: cfg.SameSite,
+	}
+}
+
+// SetAuthCookies menyimpan access & refresh token serta CSRF token baru.
+// CSRF token dikembalikan agar bisa juga dikirim di body response.
+func SetAuthCookies(c *fiber.Ctx, cfg CookieAuthConfig, accessToken string, accessExp time.Time,
+	refreshToken string, refreshExp time.Time) (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	csrf := base64.RawURLEncoding.EncodeToString(b)
+
+	c.Cookie(cfg.cookie(AccessTokenCookie, accessToken, "/", accessExp, true))
+	c.Cookie(cfg.cookie(RefreshTokenCookie, refreshToken, cfg.RefreshPath, refreshExp, true))
+	// CSRF cookie sengaja bisa dibaca JavaScript (double submit)
+	c.Cookie(cfg.cookie(CSRFCookie, csrf, "/", refreshExp, false))
+	return csrf, nil
+}
+
+// ClearAuthCookies menghapus semua cookie auth (logout)
+func ClearAuthCookies(c *fiber.Ctx, cfg CookieAuthConfig) {
+	past := time.Unix(0, 0)
+	c.Cookie(cfg.cookie(AccessTokenCookie, "", "/", past, true))
+	c.Cookie(cfg.cookie(RefreshTokenCookie, "", cfg.RefreshPath, past, true))
+	c.Cookie(cfg.cookie(CSRFCookie, "", "/", past, false))
+}
+
+// CSRFValid: method aman (GET/HEAD/OPTIONS) selalu lolos, selain itu header
+// X-CSRF-Token wajib sama dengan cookie CSRF.
+func CSRFValid(c *fiber.Ctx) bool {
+	switch c.Method() {
+	case fiber.MethodGet, fiber.MethodHead, fiber.MethodOptions:
+		return true
+	}
+	cookie := c.Cookies(CSRFCookie)
+	header := c.Get(CSRFHeader)
+	if cookie == "" || header == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(cookie), []byte(header)) == 1
+}
@@ -8,28 +8,76 @@ import (
 
 // AuthRequired adalah middleware utama untuk cek Login & Blacklist
 func AuthRequired() fiber.Handler {
+	cookieCfg := CookieAuthConfigFromEnv()
+
 	return func(c *fiber.Ctx) error {
 		// 1. Ambil Header
 		auth := c.Get("Authorization")
-		if auth == "" {
-			return c.Status(401).JSON(fiber.Map{"error": "Unauthorized", "message": "Missing token"})
+
+		// API key (integrasi sistem lain) sebagai alternatif Bearer JWT
+		if apiKey := c.Get(utils.APIKeyHeader); apiKey != "" && auth == "" {
+			return apiKeyAuth(c, apiKey)
 		}
 
 		// 2. Bersihkan Token String
 		tokenString := strings.TrimSpace(strings.Replace(auth, "Bearer", "", 1))
 
-		// 3. Cek Blacklist (Logout check)
-		if utils.IsTokenBlacklisted(tokenString) {
-			return c.Status(401).JSON(fiber.Map{"error": "Unauthorized", "message": "Token revoked"})
+		// Mode cookie: token dari cookie HttpOnly, wajib lolos cek CSRF
+		if auth == "" && cookieCfg.Enabled {
+			if tokenString = c.Cookies(AccessTokenCookie); tokenString != "" && !CSRFValid(c) {
+				return c.Status(403).JSON(fiber.Map{"error": "Forbidden", "message": "Missing or invalid CSRF token"})
+			}
+		}
+
+		if tokenString == "" {
+			return c.Status(401).JSON(fiber.Map{"error": "Unauthorized", "message": "Missing token"})
 		}
 
-		// 4. Validasi JWT & Ambil Claims
+		// 3. Validasi JWT & Ambil Claims
 		claims, err := utils.ParseAccessToken(tokenString)
 		if err != nil {
 			return c.Status(401).JSON(fiber.Map{"error": "Unauthorized", "message": "Invalid or expired token"})
 		}
 
-		// 5. Simpan ke Locals untuk digunakan di Service/Next Middleware
+		// 4. Cek Revocation Store berdasarkan jti (Logout check)
+		revoked, err := utils.IsTokenRevoked(claims.TokenID())
+		if err == nil && !revoked {
+			// Session yang sudah logout / di-revoke ikut mematikan access token-nya
+			revoked, err = utils.IsSessionRevoked(claims.SessionID)
+		}
+		if err == nil && !revoked && claims.IsImpersonation() && claims.Actor.SessionID != "" {
+			// token impersonation ikut mati saat session admin di-revoke
+			revoked, err = utils.IsSessionRevoked(claims.Actor.SessionID)
+		}
+		if err != nil {
+			return c.Status(503).JSON(fiber.Map{"error": "Service Unavailable", "message": "Failed to check token status"})
+		}
+		if revoked {
+			return c.Status(401).JSON(fiber.Map{"error": "Unauthorized", "message": "Token revoked"})
+		}
+
+		// 5. Permission diambil live dari role, bukan dari isi token
+		if claims.RoleID == "" {
+			return c.Status(401).JSON(fiber.Map{"error": "Unauthorized", "message": "Token format outdated, please login again"})
+		}
+		perms, version, err := utils.RolePermissions(claims.RoleID)
+		if err != nil {
+			return c.Status(503).JSON(fiber.Map{"error": "Service Unavailable", "message": "Failed to load permissions"})
+		}
+		if version != claims.PermVersion {
+			if utils.StalePermissionPolicy() == utils.StalePermissionReject {
+				return c.Status(401).JSON(fiber.Map{
+					"error":   "Unauthorized",
+					"message": "Permissions changed, please refresh your token",
+					"code":    "permissions_stale",
+				})
+			}
+			// token tetap dipakai dengan permission terbaru; client disarankan refresh
+			c.Set("X-Permissions-Stale", "true")
+		}
+		claims.Permissions = perms
+
+		// 6. Simpan ke Locals untuk digunakan di Service/Next Middleware
 		c.Locals("claims", claims)
 		c.Locals("token", tokenString)
 
@@ -37,24 +85,26 @@ func AuthRequired() fiber.Handler {
 	}
 }
 
-// RoleRequired digunakan SETELAH AuthRequired untuk cek hak akses
-func RoleRequired(allowedRoles ...string) fiber.Handler {
-	return func(c *fiber.Ctx) error {
-		claims, ok := c.Locals("claims").(*utils.JWTClaims)
-		if !ok {
-			return c.Status(500).JSON(fiber.Map{"error": "Internal Server Error", "message": "Claims not found"})
-		}
+// apiKeyAuth: permission = scopes milik key, tanpa session & role
+func apiKeyAuth(c *fiber.Ctx, apiKey string) error {
+	claims, err := utils.AuthenticateAPIKey(apiKey, c.IP())
+	if err == utils.ErrAPIKeyForbidden {
+		return c.Status(403).JSON(fiber.Map{"error": "Forbidden", "message": "API key is not allowed from this address"})
+	}
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"error": "Unauthorized", "message": "Invalid, expired or revoked API key"})
+	}
 
-		// Cek apakah role user ada di daftar yang diizinkan
-		for _, role := range allowedRoles {
-			if claims.Role == role {
-				return c.Next()
-			}
-		}
+	c.Locals("claims", claims)
+	return c.Next()
+}
 
-		return c.Status(403).JSON(fiber.Map{
-			"error":   "Forbidden",
-			"message": "Anda tidak memiliki akses (Role: " + claims.Role + ")",
-		})
+// HumanOnly menolak request dengan API key (endpoint akun / session / login)
+func HumanOnly() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if claims, ok := c.Locals("claims").(*utils.JWTClaims); ok && claims.IsAPIKey() {
+			return c.Status(403).JSON(fiber.Map{"error": "Forbidden", "message": "Endpoint ini tidak bisa diakses dengan API key"})
+		}
+		return c.Next()
 	}
-}
\ No newline at end of file
+}
@@ -6,6 +6,12 @@ import (
 )
 
 func RequirePermission(permission string) fiber.Handler {
+	return RequireAnyPermission(permission)
+}
+
+// RequireAnyPermission lolos jika user memiliki salah satu permission
+// (misal: endpoint yang datanya difilter berdasarkan read_own/read_all)
+func RequireAnyPermission(permissions ...string) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		claimsInterface := c.Locals("claims")
 		if claimsInterface == nil {
@@ -13,10 +19,8 @@ func RequirePermission(permission string) fiber.Handler {
 		}
 		claims := claimsInterface.(*utils.JWTClaims)
 
-		for _, p := range claims.Permissions {
-			if p == permission {
-				return c.Next()
-			}
+		if claims.HasPermission(permissions...) {
+			return c.Next()
 		}
 
 		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "forbidden: insufficient permissions"})
@@ -1,6 +1,7 @@
 package routes
 
 import (
+	"achievements-uas/app/models"
 	"achievements-uas/middleware"
 	"achievements-uas/services"
 
@@ -13,8 +14,17 @@ func SetupRoutes(
 	adminService *services.UserAdminService,
 	achievementService *services.AchievementService,
 	reportService *services.ReportService,
+	roleService *services.RoleAdminService,
+	impersonationService *services.ImpersonationService,
+	apiKeyService *services.APIKeyService,
+	invitationService *services.InvitationService,
+	scoringService *services.ScoringService,
+	approvalChainService *services.ApprovalChainService,
 ) {
 
+	// Public key (JWKS) untuk verifikasi token secara offline
+	app.Get("/.well-known/jwks.json", authService.JWKS)
+
 	api := app.Group("/api")
 	v1 := api.Group("/v1")
 
@@ -23,57 +33,146 @@ func SetupRoutes(
 	// =====================================================
 	authPublic := v1.Group("/auth")
 	authPublic.Post("/login", authService.Login)
+	authPublic.Post("/login/mfa", authService.LoginMFA)
+	authPublic.Post("/login/mfa/setup", authService.SetupMFA)
+	authPublic.Post("/login/mfa/confirm", authService.ConfirmMFA)
+	authPublic.Get("/oidc/login", authService.OIDCLogin)
+	authPublic.Get("/oidc/callback", authService.OIDCCallback)
 	authPublic.Post("/refresh", authService.Refresh)
+	authPublic.Post("/forgot-password", authService.ForgotPassword)
+	authPublic.Post("/reset-password", authService.ResetPassword)
+	authPublic.Post("/activate", invitationService.Activate)
 
 	// =====================================================
 	// 2. PROTECTED ROUTES (Wajib Login & Cek Blacklist)
 	// =====================================================
 	// Semua yang menggunakan 'protected' akan dicek oleh middleware AuthRequired
-	protected := v1.Group("/", middleware.AuthRequired())
+	// Guard impersonation: token "login sebagai" hanya read-only & diaudit
+	protected := v1.Group("/", middleware.AuthRequired(), impersonationService.Guard)
+
+	// Endpoint akun & session hanya untuk user, bukan API key
+	protected.Use("/auth", middleware.HumanOnly())
 
 	// AUTH - Profile & Logout
 	protected.Post("/auth/logout", authService.Logout)
 	protected.Get("/auth/profile", authService.Profile)
+	protected.Post("/auth/logout-all", authService.LogoutAll)
+	protected.Post("/auth/password", authService.ChangePassword)
+	protected.Post("/auth/mfa/setup", authService.SetupMFA)
+	protected.Post("/auth/mfa/confirm", authService.ConfirmMFA)
+	protected.Post("/auth/mfa/disable", authService.DisableMFA)
+	protected.Post("/auth/mfa/recovery-codes", authService.RegenerateRecoveryCodes)
+	protected.Post("/auth/impersonation/stop", impersonationService.Stop)
+	protected.Get("/auth/sessions", authService.Sessions)
+	protected.Delete("/auth/sessions/:id", authService.DeleteSession)
+
+	// USERS - FR-009
+	// Akses ditentukan permission, bukan nama role
+	// Log keamanan autentikasi (append-only)
+	audit := protected.Group("/audit", middleware.RequirePermission(models.PermUserManage))
+	audit.Get("/auth-events", authService.AuthEvents)
 
-	// USERS (ADMIN ONLY) - FR-009
-	// Kita tambahkan RoleRequired agar hanya Admin yang bisa akses
-	users := protected.Group("/users", middleware.RoleRequired("Admin"))
+	users := protected.Group("/users", middleware.RequirePermission(models.PermUserManage))
 	users.Get("/", adminService.GetAll)
 	users.Get("/:id", adminService.GetByID)
 	users.Post("/", adminService.Create)
 	users.Put("/:id", adminService.Update)
 	users.Delete("/:id", adminService.Delete)
 	users.Put("/:id/password", adminService.UpdatePassword)
+	users.Post("/:id/invitation/resend", invitationService.Resend)
+	users.Get("/:id/sessions", authService.UserSessions)
+	users.Delete("/:id/sessions", authService.DeleteUserSessions)
+	users.Delete("/:id/sessions/:sessionId", authService.DeleteUserSession)
+	users.Post("/:id/unlock", authService.UnlockUser)
+	users.Get("/:id/login-attempts", authService.UserLoginAttempts)
+	users.Delete("/:id/mfa", authService.ResetUserMFA)
+	users.Put("/:id/auth-source", authService.SetUserAuthSource)
+	users.Post("/:id/impersonate", middleware.RequirePermission(models.PermUserImpersonate), impersonationService.Start)
+
+	// IMPERSONATION AUDIT
+	impersonations := protected.Group("/impersonations", middleware.RequirePermission(models.PermUserManage))
+	impersonations.Get("/", impersonationService.List)
+	impersonations.Get("/:id/requests", impersonationService.Requests)
+
+	// ROLES & PERMISSIONS
+	roles := protected.Group("/roles", middleware.RequirePermission(models.PermRoleManage))
+	roles.Get("/", roleService.GetAll)
+	roles.Get("/:id", roleService.GetByID)
+	roles.Post("/", roleService.Create)
+	roles.Put("/:id", roleService.Update)
+	roles.Delete("/:id", roleService.Delete)
+	roles.Post("/:id/permissions", roleService.AttachPermission)
+	roles.Delete("/:id/permissions/:permissionId", roleService.DetachPermission)
+	protected.Get("/permissions", middleware.RequirePermission(models.PermRoleManage), roleService.GetPermissions)
+
+	// Rulebook poin prestasi
+	scoring := protected.Group("/scoring-rules", middleware.RequirePermission(models.PermScoringManage))
+	scoring.Get("/", scoringService.GetAll)
+	scoring.Post("/recompute", scoringService.Recompute)
+	scoring.Get("/:id", scoringService.GetByID)
+	scoring.Post("/", scoringService.Create)
+	scoring.Put("/:id", scoringService.Update)
+	scoring.Delete("/:id", scoringService.Delete)
+
+	// APPROVAL CHAINS (verifikasi bertingkat)
+	approvalChains := protected.Group("/approval-chains", middleware.RequirePermission(models.PermApprovalManage))
+	approvalChains.Get("/", approvalChainService.GetAll)
+	approvalChains.Get("/:id", approvalChainService.GetByID)
+	approvalChains.Post("/", approvalChainService.Create)
+	approvalChains.Put("/:id", approvalChainService.Update)
+	approvalChains.Delete("/:id", approvalChainService.Delete)
+
+	// API KEYS (integrasi dashboard fakultas / SKPI)
+	apiKeys := protected.Group("/api-keys", middleware.HumanOnly(), middleware.RequirePermission(models.PermAPIKeyManage))
+	apiKeys.Get("/", apiKeyService.GetAll)
+	apiKeys.Get("/:id", apiKeyService.GetByID)
+	apiKeys.Post("/", apiKeyService.Create)
+	apiKeys.Delete("/:id", apiKeyService.Revoke)
 
 	// ACHIEVEMENTS - FR-003 s/d FR-008
+	// Cakupan data (own/advisee/all) difilter lagi di service
+	readAchievement := middleware.RequireAnyPermission(
+		models.PermAchievementReadOwn, models.PermAchievementReadAdvisee, models.PermAchievementReadAll,
+	)
 	ach := protected.Group("/achievements")
-	ach.Get("/", achievementService.List)
-	ach.Get("/:id", achievementService.Detail)
-	ach.Get("/:id/history", achievementService.History)
-	ach.Post("/", achievementService.Create)
-	ach.Put("/:id", achievementService.Update)
-	ach.Post("/:id/submit", achievementService.Submit)
-	ach.Delete("/:id", achievementService.Delete)
-	ach.Post("/:id/attachments", achievementService.UploadAttachment)
-	// Verifikasi (Biasanya oleh Dosen/Admin)
-	ach.Post("/:id/verify", achievementService.Verify)
-	ach.Post("/:id/reject", achievementService.Reject)
-
-	// STUDENTS (ADMIN ONLY) - FR-009
-	students := protected.Group("/students", middleware.RoleRequired("Admin"))
+	ach.Get("/", readAchievement, achievementService.List)
+	ach.Get("/:id", readAchievement, achievementService.Detail)
+	ach.Get("/:id/history", readAchievement, achievementService.History)
+	ach.Get("/:id/actions", readAchievement, achievementService.Actions)
+	ach.Get("/:id/revision-diff", readAchievement, achievementService.RevisionDiff)
+	ach.Get("/:id/approvals", readAchievement, achievementService.Approvals)
+	ach.Get("/:id/comments", readAchievement, achievementService.ListComments)
+	ach.Post("/:id/comments", readAchievement, achievementService.CreateComment)
+	ach.Put("/:id/comments/:commentId", readAchievement, achievementService.UpdateComment)
+	ach.Delete("/:id/comments/:commentId", readAchievement, achievementService.DeleteComment)
+	ach.Post("/", middleware.RequirePermission(models.PermAchievementCreate), achievementService.Create)
+	ach.Put("/:id", middleware.RequirePermission(models.PermAchievementUpdate), achievementService.Update)
+	ach.Post("/:id/submit", middleware.RequirePermission(models.PermAchievementSubmit), achievementService.Submit)
+	ach.Post("/:id/resubmit", middleware.RequirePermission(models.PermAchievementSubmit), achievementService.Resubmit)
+	ach.Delete("/:id", middleware.RequirePermission(models.PermAchievementDelete), achievementService.Delete)
+	ach.Post("/:id/attachments", middleware.RequirePermission(models.PermAchievementUpdate), achievementService.UploadAttachment)
+	// Verifikasi (Dosen Wali / role lain yang diberi permission)
+	ach.Post("/:id/verify", middleware.RequirePermission(models.PermAchievementVerify), achievementService.Verify)
+	ach.Post("/:id/reject", middleware.RequirePermission(models.PermAchievementReject), achievementService.Reject)
+	ach.Post("/:id/request-revision", middleware.RequirePermission(models.PermAchievementRequestRevision), achievementService.RequestRevision)
+
+	// STUDENTS - FR-009
+	students := protected.Group("/students", middleware.RequirePermission(models.PermStudentManage))
 	students.Get("/", adminService.GetAllStudents)
 	students.Get("/:id", adminService.GetStudentByID)
 	students.Get("/:id/achievements", adminService.GetStudentAchievements)
 	students.Put("/:id/advisor", adminService.SetAdvisor)
 
-	// LECTURERS (ADMIN ONLY) - FR-006
-	lecturers := protected.Group("/lecturers", middleware.RoleRequired("Admin"))
+	// LECTURERS - FR-006
+	lecturers := protected.Group("/lecturers", middleware.RequirePermission(models.PermLecturerManage))
 	lecturers.Get("/", adminService.GetAllLecturers)
 	lecturers.Get("/:id/advisees", adminService.GetLecturerAdvisees)
 
 	// REPORTS & ANALYTICS - FR-011
-// Tetap gunakan AuthRequired agar sistem tahu "Siapa" yang memanggil
-reportGroup := protected.Group("/reports", middleware.AuthRequired())
+// Cakupan laporan (own/advisee/all) difilter di ReportService
+reportGroup := protected.Group("/reports", middleware.RequireAnyPermission(
+	models.PermReportReadOwn, models.PermReportReadAdvisee, models.PermReportReadAll,
+))
 reportGroup.Get("/statistics", reportService.Statistics)
 reportGroup.Get("/student/:id", reportService.StudentReport)
 }
\ No newline at end of file
@@ -0,0 +1,447 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"achievements-uas/app/models"
+	"achievements-uas/app/repository"
+	"achievements-uas/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// =====================================================
+// APPROVAL CHAIN (VERIFIKASI BERTINGKAT)
+// =====================================================
+// Chain dipilih saat prestasi diajukan: chain aktif yang paling spesifik
+// (jenis & tingkat prestasi), lalu priority tertinggi; tahapnya disalin ke
+// dokumen prestasi sehingga tidak berubah selama diproses. Tanpa chain yang
+// cocok cukup Dosen Wali. Setiap tahap dicatat di history, field approvals
+// (MongoDB) dan achievement_approvals (PostgreSQL); 'verified' hanya dari
+// tahap terakhir.
+
+// matchApprovalChain mengembalikan chain terbaik untuk prestasi, nil jika tidak ada
+func matchApprovalChain(chains []models.ApprovalChain, ach *models.Achievement) *models.ApprovalChain {
+	i := bestMatch(len(chains), func(i int) (int, int, bool) {
+		chain := &chains[i]
+		if len(chain.Stages) == 0 {
+			return 0, 0, false
+		}
+		specificity, ok := matchCriteria(achievementCriteria(chain.AchievementType, chain.CompetitionLevel, ach))
+		return specificity, chain.Priority, ok
+	})
+	if i < 0 {
+		return nil
+	}
+	return &chains[i]
+}
+
+// resolveApprovalChain memilih chain dari data prestasi saat ini
+func (s *AchievementService) resolveApprovalChain(ach *models.Achievement) (*models.ApprovalChain, error) {
+	if s.ApprovalRepo != nil {
+		chains, err := s.ApprovalRepo.FindAll(true)
+		if err != nil {
+			return nil, err
+		}
+		if chain := matchApprovalChain(chains, ach); chain != nil {
+			return chain, nil
+		}
+	}
+	chain := models.DefaultApprovalChain
+	return &chain, nil
+}
+
+// approvalChainFor: salinan chain yang disimpan saat diajukan. Prestasi yang
+// belum pernah diajukan / disetujui dengan chain dipilih dari datanya.
+func (s *AchievementService) approvalChainFor(ach *models.Achievement) (*models.ApprovalChain, error) {
+	if len(ach.ApprovalStages) > 0 {
+		return &models.ApprovalChain{
+			ID:     ach.ApprovalChainID,
+			Name:   ach.ApprovalChainName,
+			Stages: ach.ApprovalStages,
+		}, nil
+	}
+	return s.resolveApprovalChain(ach)
+}
+
+// currentStage: tahap yang menunggu persetujuan (nil jika chain gagal dimuat).
+// Setelah tahap terakhir disetujui, tahap terakhir yang dikembalikan.
+func (s *AchievementService) currentStage(ach *models.Achievement) (*models.ApprovalChain, *models.ApprovalStage) {
+	chain, err := s.approvalChainFor(ach)
+	if err != nil {
+		log.Printf("[WARN] load approval chain %s: %v", ach.ID.Hex(), err)
+		return nil, nil
+	}
+	idx := ach.ApprovalStage
+	if idx >= len(chain.Stages) {
+		idx = len(chain.Stages) - 1
+	}
+	return chain, &chain.Stages[idx]
+}
+
+func (s *AchievementService) isLastStage(ach *models.Achievement) bool {
+	chain, stage := s.currentStage(ach)
+	return stage != nil && stage.Order == chain.Stages[len(chain.Stages)-1].Order
+}
+
+// isStageApprover: pemilik approval:override boleh di tahap mana pun, tapi
+// satu user tidak boleh menyetujui dua tahap pada pengajuan yang sama
+func (s *AchievementService) isStageApprover(claims *utils.JWTClaims, ach *models.Achievement, stage *models.ApprovalStage) bool {
+	if stage == nil {
+		return false
+	}
+	for _, a := range currentRoundApprovals(ach) {
+		if a.ApprovedByID == claims.ID {
+			return false
+		}
+	}
+	if claims.HasPermission(models.PermApprovalOverride) {
+		return true
+	}
+
+	switch stage.ApproverType {
+	case models.ApproverAdvisor:
+		isAdvisee, err := s.AdminRepo.CheckIsAdvisee(ach.StudentID, claims.ID)
+		return err == nil && isAdvisee
+	case models.ApproverRole:
+		return stage.RoleID != nil && *stage.RoleID == claims.RoleID
+	}
+	return false
+}
+
+// currentRoundApprovals: persetujuan sejak pengajuan terakhir (ada di akhir approvals)
+func currentRoundApprovals(ach *models.Achievement) []models.StageApproval {
+	n := ach.ApprovalStage
+	if n > len(ach.Approvals) {
+		n = len(ach.Approvals)
+	}
+	return ach.Approvals[len(ach.Approvals)-n:]
+}
+
+// prepareSubmit memasang approval chain dan mengulang dari tahap pertama
+// (dipakai submit & resubmit, karena jenis/tingkat bisa berubah saat revisi)
+func (s *AchievementService) prepareSubmit(c *fiber.Ctx, claims *utils.JWTClaims, ach *models.Achievement) (*transitionExtra, *workflowError) {
+	chain, err := s.resolveApprovalChain(ach)
+	if err != nil {
+		return nil, &workflowError{500, "failed load approval chain"}
+	}
+	return &transitionExtra{
+		Set: bson.M{
+			"approvalChainId":   chain.ID,
+			"approvalChainName": chain.Name,
+			"approvalStages":    chain.Stages,
+			"approvalStage":     0,
+		},
+		Apply: func(a *models.Achievement) {
+			a.ApprovalChainID, a.ApprovalChainName, a.ApprovalStages = chain.ID, chain.Name, chain.Stages
+			a.ApprovalStage = 0
+		},
+	}, nil
+}
+
+// prepareApproval mencatat persetujuan tahap yang sedang berjalan
+func (s *AchievementService) prepareApproval(c *fiber.Ctx, claims *utils.JWTClaims, ach *models.Achievement) (*transitionExtra, *workflowError) {
+	chain, stage := s.currentStage(ach)
+	if stage == nil {
+		return nil, &workflowError{500, "failed load approval chain"}
+	}
+
+	approval := models.StageApproval{
+		ChainID:      chain.ID,
+		Stage:        stage.Order,
+		StageName:    stage.Name,
+		ApprovedBy:   claims.Username,
+		ApprovedByID: claims.ID,
+		ApprovedAt:   time.Now(),
+	}
+	next := ach.ApprovalStage + 1
+
+	// tahap belum pernah disetujui: field approvalStage bisa belum ada
+	var match bson.M
+	if ach.ApprovalStage == 0 {
+		match = bson.M{"approvalStage": bson.M{"$in": bson.A{0, nil}}}
+	} else {
+		match = bson.M{"approvalStage": ach.ApprovalStage}
+	}
+
+	// chain ikut disalin agar pengajuan lama (tanpa salinan) juga terkunci
+	return &transitionExtra{
+		Set: bson.M{
+			"approvalStage":     next,
+			"approvalChainId":   chain.ID,
+			"approvalChainName": chain.Name,
+			"approvalStages":    chain.Stages,
+		},
+		Push:  bson.M{"approvals": approval},
+		Match: match,
+		Note:  fmt.Sprintf("(tahap %d/%d: %s)", stage.Order, len(chain.Stages), stage.Name),
+		Sync: func(ctx context.Context, action *WorkflowAction, update func() error) error {
+			return s.syncStageApproval(ctx, ach, action, approval, update)
+		},
+		Apply: func(a *models.Achievement) {
+			a.ApprovalStage, a.ApprovalChainID = next, chain.ID
+			a.ApprovalChainName, a.ApprovalStages = chain.Name, chain.Stages
+			a.Approvals = append(a.Approvals, approval)
+		},
+	}, nil
+}
+
+// syncStageApproval menulis jejak tahap ke PostgreSQL dalam transaksi yang
+// baru di-commit setelah update MongoDB berhasil. Jika commit gagal setelah
+// MongoDB berubah, dokumen dikembalikan ke kondisi sebelum persetujuan.
+func (s *AchievementService) syncStageApproval(ctx context.Context, ach *models.Achievement, action *WorkflowAction,
+	approval models.StageApproval, update func() error) error {
+	var chainID *string
+	if approval.ChainID != "" {
+		chainID = &approval.ChainID
+	}
+	rec := repository.StageApprovalRecord{
+		ID:         uuid.New().String(),
+		MongoID:    ach.ID.Hex(),
+		ChainID:    chainID,
+		Stage:      approval.Stage,
+		StageName:  approval.StageName,
+		ApprovedBy: approval.ApprovedByID,
+		Status:     action.To,
+	}
+
+	updated := false
+	err := s.PgRepo.RecordStageApproval(ctx, rec, func() error {
+		if err := update(); err != nil {
+			return err
+		}
+		updated = true
+		return nil
+	})
+	if err != nil && updated {
+		// ctx bisa sudah habis; pembatalan tetap harus dijalankan
+		s.revertStageApproval(context.Background(), ach, action.To)
+	}
+	return err
+}
+
+// revertStageApproval membatalkan update MongoDB dari prepareApproval
+// (status, approvalStage, serta history & approval terakhir)
+func (s *AchievementService) revertStageApproval(ctx context.Context, ach *models.Achievement, status string) {
+	update := bson.M{
+		"$set": bson.M{"status": ach.Status, "approvalStage": ach.ApprovalStage, "updatedAt": time.Now()},
+		"$pop": bson.M{"history": 1, "approvals": 1},
+	}
+	ok, err := s.MongoRepo.UpdateIfStatus(ctx, ach.ID, status, bson.M{"approvalStage": ach.ApprovalStage + 1}, update)
+	if err != nil || !ok {
+		log.Printf("[ERROR] revert stage approval %s (ok=%v): %v", ach.ID.Hex(), ok, err)
+	}
+}
+
+// latestApproval: persetujuan tahap yang baru saja dicatat
+func latestApproval(ach *models.Achievement) *models.StageApproval {
+	if len(ach.Approvals) == 0 {
+		return nil
+	}
+	last := ach.Approvals[len(ach.Approvals)-1]
+	return &last
+}
+
+// GET /api/v1/achievements/:id/approvals
+// Tahapan chain beserta status persetujuan pada pengajuan saat ini
+func (s *AchievementService) Approvals(c *fiber.Ctx) error {
+	claims := c.Locals("claims").(*utils.JWTClaims)
+	ach, err := s.loadReadableAchievement(c, claims)
+	if ach == nil {
+		return err
+	}
+
+	chain, current := s.currentStage(ach)
+	if chain == nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed load approval chain"})
+	}
+
+	approved := map[int]models.StageApproval{}
+	for _, a := range currentRoundApprovals(ach) {
+		approved[a.Stage] = a
+	}
+
+	stages := []fiber.Map{}
+	for _, st := range chain.Stages {
+		item := fiber.Map{"stage": st}
+		if a, ok := approved[st.Order]; ok {
+			item["state"] = "approved"
+			item["approval"] = a
+		} else if st.Order == current.Order && (ach.Status == models.AchievementStatusSubmitted ||
+			ach.Status == models.AchievementStatusPartiallyApproved) {
+			item["state"] = "pending"
+		} else {
+			item["state"] = "waiting"
+		}
+		stages = append(stages, item)
+	}
+
+	return c.JSON(fiber.Map{
+		"id":       ach.ID.Hex(),
+		"status":   ach.Status,
+		"chain_id": chain.ID,
+		"chain":    chain.Name,
+		"stages":   stages,
+		"history":  ach.Approvals,
+	})
+}
+
+type ApprovalChainService struct {
+	Repo     *repository.ApprovalChainRepository
+	RoleRepo *repository.RoleRepository
+}
+
+func NewApprovalChainService(repo *repository.ApprovalChainRepository, roleRepo *repository.RoleRepository) *ApprovalChainService {
+	return &ApprovalChainService{
+		Repo:     repo,
+		RoleRepo: roleRepo,
+	}
+}
+
+// ==============================================
+// GET /api/v1/approval-chains
+// ==============================================
+func (s *ApprovalChainService) GetAll(c *fiber.Ctx) error {
+	chains, err := s.Repo.FindAll(false)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed get approval chains"})
+	}
+	return c.JSON(fiber.Map{"status": "success", "data": chains, "default": models.DefaultApprovalChain})
+}
+
+// ==============================================
+// GET /api/v1/approval-chains/:id
+// ==============================================
+func (s *ApprovalChainService) GetByID(c *fiber.Ctx) error {
+	chain, err := s.Repo.FindByID(c.Params("id"))
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "approval chain not found"})
+	}
+	return c.JSON(fiber.Map{"status": "success", "data": chain})
+}
+
+// approvalChainInput: urutan tahap mengikuti urutan array stages
+type approvalChainInput struct {
+	Name             string  `json:"name"`
+	AchievementType  *string `json:"achievement_type"`
+	CompetitionLevel *string `json:"competition_level"`
+	Priority         int     `json:"priority"`
+	IsActive         *bool   `json:"is_active"`
+	Stages           []struct {
+		Name         string  `json:"name"`
+		ApproverType string  `json:"approver_type"`
+		RoleID       *string `json:"role_id"`
+	} `json:"stages"`
+}
+
+func (s *ApprovalChainService) toChain(in *approvalChainInput, chain *models.ApprovalChain) error {
+	in.Name = strings.TrimSpace(in.Name)
+	if in.Name == "" {
+		return errors.New("name is required")
+	}
+	if len(in.Stages) == 0 {
+		return errors.New("at least one stage is required")
+	}
+
+	stages := make([]models.ApprovalStage, 0, len(in.Stages))
+	for i, st := range in.Stages {
+		stage := models.ApprovalStage{
+			Order:        i + 1,
+			Name:         strings.TrimSpace(st.Name),
+			ApproverType: st.ApproverType,
+		}
+		if stage.Name == "" {
+			return fmt.Errorf("stages[%d].name is required", i)
+		}
+		switch st.ApproverType {
+		case models.ApproverAdvisor:
+		case models.ApproverRole:
+			if st.RoleID == nil || *st.RoleID == "" {
+				return fmt.Errorf("stages[%d].role_id is required for approver_type 'role'", i)
+			}
+			if _, err := s.RoleRepo.FindByID(*st.RoleID); err != nil {
+				return fmt.Errorf("stages[%d]: role not found", i)
+			}
+			stage.RoleID = st.RoleID
+		default:
+			return fmt.Errorf("stages[%d].approver_type must be 'advisor' or 'role'", i)
+		}
+		stages = append(stages, stage)
+	}
+
+	chain.Name = in.Name
+	chain.AchievementType = optionalCriterion(in.AchievementType)
+	chain.CompetitionLevel = optionalCriterion(in.CompetitionLevel)
+	chain.Priority = in.Priority
+	chain.IsActive = in.IsActive == nil || *in.IsActive
+	chain.Stages = stages
+	return nil
+}
+
+// ==============================================
+// POST /api/v1/approval-chains
+// ==============================================
+func (s *ApprovalChainService) Create(c *fiber.Ctx) error {
+	var in approvalChainInput
+	if err := c.BodyParser(&in); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid input"})
+	}
+
+	chain := &models.ApprovalChain{ID: uuid.New().String()}
+	if err := s.toChain(&in, chain); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err := s.Repo.Create(chain); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed create approval chain"})
+	}
+
+	chain.CreatedAt, chain.UpdatedAt = time.Now(), time.Now()
+	return c.Status(201).JSON(fiber.Map{"status": "success", "data": chain})
+}
+
+// ==============================================
+// PUT /api/v1/approval-chains/:id
+// ==============================================
+// Prestasi yang sedang diproses tetap memakai tahap saat diajukan.
+func (s *ApprovalChainService) Update(c *fiber.Ctx) error {
+	chain, err := s.Repo.FindByID(c.Params("id"))
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "approval chain not found"})
+	}
+
+	var in approvalChainInput
+	if err := c.BodyParser(&in); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid input"})
+	}
+	if err := s.toChain(&in, chain); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	if _, err := s.Repo.Update(chain); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed update approval chain"})
+	}
+
+	chain.UpdatedAt = time.Now()
+	return c.JSON(fiber.Map{"status": "success", "data": chain})
+}
+
+// ==============================================
+// DELETE /api/v1/approval-chains/:id
+// ==============================================
+// Prestasi yang sedang diproses tetap memakai salinan tahap saat diajukan.
+func (s *ApprovalChainService) Delete(c *fiber.Ctx) error {
+	deleted, err := s.Repo.Delete(c.Params("id"))
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed delete approval chain"})
+	}
+	if !deleted {
+		return c.Status(404).JSON(fiber.Map{"error": "approval chain not found"})
+	}
+	return c.JSON(fiber.Map{"status": "success", "message": "approval chain deleted"})
+}
@@ -0,0 +1,275 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"achievements-uas/app/models"
+	"achievements-uas/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// =====================================================
+// KOMENTAR / DISKUSI PRESTASI
+// =====================================================
+// Thread diskusi per prestasi antara mahasiswa dan dosen. Siapa pun yang boleh
+// melihat detail prestasi (canRead) boleh membaca dan menulis komentar.
+// Penulis bisa mengubah / menghapus komentarnya selama COMMENT_EDIT_WINDOW.
+
+const commentMaxLength = 2000
+
+var mentionPattern = regexp.MustCompile(`(?:^|[^A-Za-z0-9_.@])@([A-Za-z0-9_.\-]+)`)
+
+func commentEditWindow() time.Duration {
+	d, err := time.ParseDuration(os.Getenv("COMMENT_EDIT_WINDOW"))
+	if err != nil {
+		return 15 * time.Minute
+	}
+	return d
+}
+
+// parseMentions mengambil username unik dari "@username" di isi komentar
+func parseMentions(body string) []string {
+	seen := map[string]bool{}
+	names := []string{}
+	for _, m := range mentionPattern.FindAllStringSubmatch(body, -1) {
+		name := strings.TrimRight(m[1], ".-")
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		names = append(names, name)
+	}
+	return names
+}
+
+// resolveMentions hanya menerima peserta thread: mahasiswa pemilik, dosen wali,
+// dan user yang sudah pernah berkomentar. Mention lain diabaikan agar
+// notifikasi tidak membocorkan prestasi ke user yang tidak berhak.
+func (s *AchievementService) resolveMentions(ach *models.Achievement, existing []models.AchievementComment, body, authorID string) ([]models.CommentMention, []models.User) {
+	names := parseMentions(body)
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	allowed := map[string]bool{}
+	if ids, err := s.AdminRepo.GetAchievementParticipantIDs(ach.StudentID); err == nil {
+		for _, id := range ids {
+			allowed[id] = true
+		}
+	}
+	for _, cm := range existing {
+		allowed[cm.AuthorID] = true
+	}
+
+	users, err := s.AdminRepo.FindActiveUsersByUsernames(names)
+	if err != nil {
+		log.Printf("[WARN] resolve mentions: %v", err)
+		return nil, nil
+	}
+
+	mentions := []models.CommentMention{}
+	notify := []models.User{}
+	for _, u := range users {
+		if !allowed[u.ID] {
+			continue
+		}
+		mentions = append(mentions, models.CommentMention{UserID: u.ID, Username: u.Username})
+		if u.ID != authorID {
+			notify = append(notify, u)
+		}
+	}
+	return mentions, notify
+}
+
+func (s *AchievementService) notifyMentions(users []models.User, ach *models.Achievement, author string) {
+	if s.Mailer == nil {
+		return
+	}
+	link := fmt.Sprintf("%s/achievements/%s", os.Getenv("APP_BASE_URL"), ach.ID.Hex())
+	for _, u := range users {
+		if u.Email == "" {
+			continue
+		}
+		body := fmt.Sprintf(
+			"Halo %s,\n\n%s menyebut Anda dalam diskusi prestasi \"%s\".\n\nLihat diskusi: %s\n",
+			u.FullName, author, ach.Title, link,
+		)
+		if err := s.Mailer.Send(u.Email, "Anda disebut dalam diskusi prestasi", body); err != nil {
+			log.Printf("[WARN] mention mail to %s: %v", u.Username, err)
+		}
+	}
+}
+
+// loadReadableAchievement: 404 jika tidak ada, 403 jika tidak boleh dilihat (sama seperti Detail)
+func (s *AchievementService) loadReadableAchievement(c *fiber.Ctx, claims *utils.JWTClaims) (*models.Achievement, error) {
+	ach, err := s.MongoRepo.GetByID(context.Background(), c.Params("id"))
+	if err != nil {
+		return nil, c.Status(404).JSON(fiber.Map{"error": "Prestasi tidak ditemukan"})
+	}
+	if !s.canRead(claims, ach.StudentID) {
+		return nil, c.Status(403).JSON(fiber.Map{"error": "Akses Ditolak: Anda tidak berhak melihat prestasi ini"})
+	}
+	return ach, nil
+}
+
+func readCommentBody(c *fiber.Ctx) (string, error) {
+	var body struct {
+		Body string `json:"body"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return "", fmt.Errorf("invalid input")
+	}
+	text := strings.TrimSpace(body.Body)
+	if text == "" {
+		return "", fmt.Errorf("body is required")
+	}
+	if len([]rune(text)) > commentMaxLength {
+		return "", fmt.Errorf("body must be at most %d characters", commentMaxLength)
+	}
+	return text, nil
+}
+
+// GET /api/v1/achievements/:id/comments
+func (s *AchievementService) ListComments(c *fiber.Ctx) error {
+	claims := c.Locals("claims").(*utils.JWTClaims)
+	ach, err := s.loadReadableAchievement(c, claims)
+	if ach == nil {
+		return err
+	}
+
+	list, err := s.CommentRepo.FindByAchievement(context.Background(), ach.ID.Hex())
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed get comments"})
+	}
+	return c.JSON(fiber.Map{"status": "success", "data": list})
+}
+
+// POST /api/v1/achievements/:id/comments
+// body: {"body": "Mohon lampirkan sertifikat, @dosen01"}
+func (s *AchievementService) CreateComment(c *fiber.Ctx) error {
+	ctx := context.Background()
+	claims := c.Locals("claims").(*utils.JWTClaims)
+	ach, err := s.loadReadableAchievement(c, claims)
+	if ach == nil {
+		return err
+	}
+	if ach.Status == models.AchievementStatusDeleted {
+		return c.Status(409).JSON(fiber.Map{"error": "cannot comment on a deleted achievement"})
+	}
+
+	text, err := readCommentBody(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	existing, err := s.CommentRepo.FindByAchievement(ctx, ach.ID.Hex())
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed get comments"})
+	}
+	mentions, notify := s.resolveMentions(ach, existing, text, claims.ID)
+
+	cm := &models.AchievementComment{
+		AchievementID:  ach.ID.Hex(),
+		AuthorID:       claims.ID,
+		AuthorUsername: claims.Username,
+		Body:           text,
+		Mentions:       mentions,
+		CreatedAt:      time.Now(),
+	}
+	if err := s.CommentRepo.Create(ctx, cm); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed create comment"})
+	}
+
+	s.notifyMentions(notify, ach, claims.Username)
+	return c.Status(201).JSON(fiber.Map{"status": "success", "data": cm})
+}
+
+// loadOwnComment: hanya penulis, belum dihapus, dan masih dalam jendela edit
+func (s *AchievementService) loadOwnComment(c *fiber.Ctx, claims *utils.JWTClaims, ach *models.Achievement) (*models.AchievementComment, error) {
+	cm, err := s.CommentRepo.FindByID(context.Background(), ach.ID.Hex(), c.Params("commentId"))
+	if err != nil {
+		return nil, c.Status(404).JSON(fiber.Map{"error": "comment not found"})
+	}
+	if cm.DeletedAt != nil {
+		return nil, c.Status(404).JSON(fiber.Map{"error": "comment not found"})
+	}
+	if cm.AuthorID != claims.ID {
+		return nil, c.Status(403).JSON(fiber.Map{"error": "only the author can change this comment"})
+	}
+	if time.Since(cm.CreatedAt) > commentEditWindow() {
+		return nil, c.Status(409).JSON(fiber.Map{"error": "edit window has expired"})
+	}
+	return cm, nil
+}
+
+// PUT /api/v1/achievements/:id/comments/:commentId
+func (s *AchievementService) UpdateComment(c *fiber.Ctx) error {
+	ctx := context.Background()
+	claims := c.Locals("claims").(*utils.JWTClaims)
+	ach, err := s.loadReadableAchievement(c, claims)
+	if ach == nil {
+		return err
+	}
+	cm, err := s.loadOwnComment(c, claims, ach)
+	if cm == nil {
+		return err
+	}
+
+	text, err := readCommentBody(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	existing, err := s.CommentRepo.FindByAchievement(ctx, ach.ID.Hex())
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed get comments"})
+	}
+	mentions, notify := s.resolveMentions(ach, existing, text, claims.ID)
+
+	now := time.Now()
+	if err := s.CommentRepo.UpdateBody(ctx, cm.ID, text, mentions, now); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed update comment"})
+	}
+
+	// notifikasi hanya untuk mention yang baru ditambahkan
+	already := map[string]bool{}
+	for _, m := range cm.Mentions {
+		already[m.UserID] = true
+	}
+	fresh := []models.User{}
+	for _, u := range notify {
+		if !already[u.ID] {
+			fresh = append(fresh, u)
+		}
+	}
+	s.notifyMentions(fresh, ach, claims.Username)
+
+	cm.Body, cm.Mentions, cm.EditedAt = text, mentions, &now
+	return c.JSON(fiber.Map{"status": "success", "data": cm})
+}
+
+// DELETE /api/v1/achievements/:id/comments/:commentId
+// Soft delete: komentar tetap ada di thread tanpa isi
+func (s *AchievementService) DeleteComment(c *fiber.Ctx) error {
+	claims := c.Locals("claims").(*utils.JWTClaims)
+	ach, err := s.loadReadableAchievement(c, claims)
+	if ach == nil {
+		return err
+	}
+	cm, err := s.loadOwnComment(c, claims, ach)
+	if cm == nil {
+		return err
+	}
+
+	if err := s.CommentRepo.SoftDelete(context.Background(), cm.ID, time.Now()); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed delete comment"})
+	}
+	return c.JSON(fiber.Map{"status": "success", "message": "comment deleted"})
+}
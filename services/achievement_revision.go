@@ -0,0 +1,232 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"achievements-uas/app/models"
+	"achievements-uas/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// =====================================================
+// REVISION REQUEST (komentar per field)
+// =====================================================
+// Dosen meminta revisi dengan komentar pada field / lampiran tertentu.
+// Mahasiswa mengubah data selama status 'revision_requested', lalu
+// mengajukan ulang. Dosen melihat diff terhadap snapshot saat komentar dibuat.
+
+// Field top-level yang boleh dikomentari; detail memakai "details.<nama>"
+var revisionFields = map[string]bool{
+	"achievementType": true,
+	"title":           true,
+	"description":     true,
+	"details":         true,
+	"tags":            true,
+	"attachments":     true,
+}
+
+func validRevisionField(field string) bool {
+	if revisionFields[field] {
+		return true
+	}
+	return strings.HasPrefix(field, "details.") && len(field) > len("details.")
+}
+
+// prepareRevisionRequest membaca komentar dari body dan menyimpannya bersama snapshot
+func prepareRevisionRequest(c *fiber.Ctx, claims *utils.JWTClaims, ach *models.Achievement) (*transitionExtra, *workflowError) {
+	var body struct {
+		Comments []models.RevisionComment `json:"comments"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return nil, &workflowError{400, "invalid input"}
+	}
+
+	for i := range body.Comments {
+		cm := &body.Comments[i]
+		cm.Field = strings.TrimSpace(cm.Field)
+		cm.Comment = strings.TrimSpace(cm.Comment)
+		if cm.Comment == "" {
+			return nil, &workflowError{400, "comment is required for every entry"}
+		}
+		if !validRevisionField(cm.Field) {
+			return nil, &workflowError{400, "unknown field: " + cm.Field}
+		}
+		if cm.AttachmentURL != "" {
+			if cm.Field != "attachments" {
+				return nil, &workflowError{400, "attachment_url is only allowed for field 'attachments'"}
+			}
+			if !hasAttachment(ach, cm.AttachmentURL) {
+				return nil, &workflowError{400, "attachment not found: " + cm.AttachmentURL}
+			}
+		}
+	}
+
+	req := models.RevisionRequest{
+		ID:            uuid.New().String(),
+		RequestedBy:   claims.Username,
+		RequestedByID: claims.ID,
+		RequestedAt:   time.Now(),
+		Comments:      body.Comments,
+		Snapshot:      ach.Snapshot(),
+	}
+	return &transitionExtra{Push: map[string]interface{}{"revisionRequests": req}}, nil
+}
+
+func hasAttachment(ach *models.Achievement, url string) bool {
+	for _, a := range ach.Attachments {
+		if a.FileURL == url {
+			return true
+		}
+	}
+	return false
+}
+
+// POST /api/v1/achievements/:id/request-revision
+// body: {"comments": [{"field": "details.rank", "comment": "..."}, ...]}
+func (s *AchievementService) RequestRevision(c *fiber.Ctx) error {
+	ach, action, _, err := s.runTransitionWith(c, ActionRequestRevision, prepareRevisionRequest)
+	if err != nil {
+		return respondWorkflowError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Permintaan revisi berhasil dikirim",
+		"id":      ach.ID.Hex(),
+		"status":  action.To,
+	})
+}
+
+// POST /api/v1/achievements/:id/resubmit
+func (s *AchievementService) Resubmit(c *fiber.Ctx) error {
+	_, action, _, err := s.runTransitionWith(c, ActionResubmit, s.prepareSubmit)
+	if err != nil {
+		return respondWorkflowError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Achievement resubmitted successfully",
+		"status":  action.To,
+	})
+}
+
+// GET /api/v1/achievements/:id/revision-diff
+// Perubahan sejak permintaan revisi terakhir, ditandai apakah field dikomentari
+func (s *AchievementService) RevisionDiff(c *fiber.Ctx) error {
+	claims := c.Locals("claims").(*utils.JWTClaims)
+
+	ach, err := s.MongoRepo.GetByID(context.Background(), c.Params("id"))
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Prestasi tidak ditemukan"})
+	}
+	if !s.canRead(claims, ach.StudentID) {
+		return c.Status(403).JSON(fiber.Map{"error": "Akses Ditolak: Anda tidak berhak melihat prestasi ini"})
+	}
+	if len(ach.RevisionRequests) == 0 {
+		return c.Status(404).JSON(fiber.Map{"error": "no revision request for this achievement"})
+	}
+
+	req := ach.RevisionRequests[len(ach.RevisionRequests)-1]
+	before := flattenSnapshot(req.Snapshot)
+	after := flattenSnapshot(ach.Snapshot())
+
+	commented := map[string]bool{}
+	for _, cm := range req.Comments {
+		commented[cm.Field] = true
+	}
+
+	keys := map[string]bool{}
+	for k := range before {
+		keys[k] = true
+	}
+	for k := range after {
+		keys[k] = true
+	}
+	fields := make([]string, 0, len(keys))
+	for k := range keys {
+		fields = append(fields, k)
+	}
+	sort.Strings(fields)
+
+	changes := []fiber.Map{}
+	changed := map[string]bool{}
+	for _, f := range fields {
+		if reflect.DeepEqual(before[f], after[f]) {
+			continue
+		}
+		changed[f] = true
+		changes = append(changes, fiber.Map{
+			"field":     f,
+			"before":    before[f],
+			"after":     after[f],
+			"commented": isCommented(commented, f),
+		})
+	}
+
+	// field yang dikomentari tapi belum diubah mahasiswa
+	unresolved := []string{}
+	for _, cm := range req.Comments {
+		if !fieldChanged(changed, cm.Field) {
+			unresolved = append(unresolved, cm.Field)
+		}
+	}
+
+	return c.JSON(fiber.Map{
+		"id":                  ach.ID.Hex(),
+		"status":              ach.Status,
+		"revision_request":    req,
+		"changes":             changes,
+		"unchanged_commented": unresolved,
+	})
+}
+
+// flattenSnapshot mengubah snapshot menjadi map "details.rank" -> nilai (nama JSON)
+func flattenSnapshot(snap models.AchievementSnapshot) map[string]interface{} {
+	raw, _ := json.Marshal(snap)
+	var m map[string]interface{}
+	_ = json.Unmarshal(raw, &m)
+
+	out := map[string]interface{}{}
+	var walk func(prefix string, v interface{})
+	walk = func(prefix string, v interface{}) {
+		if obj, ok := v.(map[string]interface{}); ok && prefix == "details" {
+			for k, child := range obj {
+				walk(prefix+"."+k, child)
+			}
+			return
+		}
+		out[prefix] = v
+	}
+	for k, v := range m {
+		walk(k, v)
+	}
+	return out
+}
+
+// isCommented: "details" juga mencakup semua "details.*"
+func isCommented(commented map[string]bool, field string) bool {
+	if commented[field] {
+		return true
+	}
+	return strings.HasPrefix(field, "details.") && commented["details"]
+}
+
+func fieldChanged(changed map[string]bool, field string) bool {
+	if changed[field] {
+		return true
+	}
+	if field == "details" {
+		for f := range changed {
+			if strings.HasPrefix(f, "details.") {
+				return true
+			}
+		}
+	}
+	return false
+}
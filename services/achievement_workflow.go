@@ -0,0 +1,469 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"achievements-uas/app/models"
+	"achievements-uas/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// =====================================================
+// ACHIEVEMENT WORKFLOW (STATE MACHINE)
+// =====================================================
+// Satu-satunya definisi status, transisi, permission, dan input wajib
+// untuk prestasi. Semua handler di AchievementService lewat checkAction
+// sehingga aturan tidak lagi tersebar di perbandingan string.
+
+// Nama action yang bisa dilakukan pada prestasi
+const (
+	ActionUpdate = "update"
+	ActionAttach = "attach"
+	ActionSubmit = "submit"
+	ActionDelete = "delete"
+	ActionVerify = "verify"
+	ActionReject = "reject"
+
+	ActionRequestRevision = "request_revision"
+	ActionResubmit        = "resubmit"
+	// persetujuan tahap approval chain yang bukan tahap terakhir
+	ActionApprove = "approve"
+)
+
+// Siapa yang boleh melakukan action selain punya permission
+const (
+	ActorOwner    = "owner"    // mahasiswa pemilik prestasi
+	ActorReviewer = "reviewer" // approver tahap approval chain yang sedang berjalan
+)
+
+// Syarat posisi tahap approval chain untuk action persetujuan
+const (
+	StageIntermediate = "intermediate" // masih ada tahap setelah tahap ini
+	StageFinal        = "final"        // tahap terakhir
+)
+
+type WorkflowAction struct {
+	Name       string
+	From       []string
+	To         string // kosong = data berubah tapi status tetap (edit, lampiran)
+	Permission string
+	Actor      string
+	// field body yang wajib diisi (misal "reason" untuk reject)
+	RequiredInputs []string
+	// catatan history, input "reason" ditambahkan di belakangnya
+	Note string
+	// kosong = tidak bergantung pada tahap approval chain
+	Stage string
+}
+
+type Workflow struct {
+	Initial string
+	States  []string
+	Actions []WorkflowAction
+}
+
+var achievementWorkflow = Workflow{
+	Initial: models.AchievementStatusDraft,
+	States: []string{
+		models.AchievementStatusDraft,
+		models.AchievementStatusSubmitted,
+		models.AchievementStatusRevisionRequested,
+		models.AchievementStatusPartiallyApproved,
+		models.AchievementStatusVerified,
+		models.AchievementStatusRejected,
+		models.AchievementStatusDeleted,
+	},
+	// Setelah diajukan, mahasiswa hanya bisa mengubah data jika dosen meminta
+	// revisi; prestasi 'rejected' tidak bisa diubah, hanya bisa diajukan
+	// ulang apa adanya atau dihapus. Verifikasi
+	// melewati setiap tahap approval chain; approver tahap yang sedang
+	// berjalan juga boleh menolak atau meminta revisi.
+	Actions: []WorkflowAction{
+		{
+			Name:       ActionUpdate,
+			From:       []string{models.AchievementStatusDraft, models.AchievementStatusRevisionRequested},
+			Permission: models.PermAchievementUpdate,
+			Actor:      ActorOwner,
+			Note:       "Melakukan perubahan data prestasi",
+		},
+		{
+			Name:       ActionAttach,
+			From:       []string{models.AchievementStatusDraft, models.AchievementStatusRevisionRequested},
+			Permission: models.PermAchievementUpdate,
+			Actor:      ActorOwner,
+		},
+		{
+			Name:       ActionSubmit,
+			From:       []string{models.AchievementStatusDraft, models.AchievementStatusRejected},
+			To:         models.AchievementStatusSubmitted,
+			Permission: models.PermAchievementSubmit,
+			Actor:      ActorOwner,
+			Note:       "Mahasiswa mengajukan verifikasi prestasi",
+		},
+		{
+			Name:       ActionResubmit,
+			From:       []string{models.AchievementStatusRevisionRequested},
+			To:         models.AchievementStatusSubmitted,
+			Permission: models.PermAchievementSubmit,
+			Actor:      ActorOwner,
+			Note:       "Mahasiswa mengajukan ulang prestasi setelah revisi",
+		},
+		{
+			Name:       ActionDelete,
+			From:       []string{models.AchievementStatusDraft, models.AchievementStatusRejected},
+			To:         models.AchievementStatusDeleted,
+			Permission: models.PermAchievementDelete,
+			Actor:      ActorOwner,
+			Note:       "Prestasi dihapus oleh mahasiswa",
+		},
+		{
+			Name:       ActionApprove,
+			From:       []string{models.AchievementStatusSubmitted, models.AchievementStatusPartiallyApproved},
+			To:         models.AchievementStatusPartiallyApproved,
+			Permission: models.PermAchievementVerify,
+			Actor:      ActorReviewer,
+			Note:       "Disetujui",
+			Stage:      StageIntermediate,
+		},
+		{
+			Name:       ActionVerify,
+			From:       []string{models.AchievementStatusSubmitted, models.AchievementStatusPartiallyApproved},
+			To:         models.AchievementStatusVerified,
+			Permission: models.PermAchievementVerify,
+			Actor:      ActorReviewer,
+			Note:       "Prestasi telah diverifikasi dan disetujui",
+			Stage:      StageFinal,
+		},
+		{
+			Name:           ActionRequestRevision,
+			From:           []string{models.AchievementStatusSubmitted, models.AchievementStatusPartiallyApproved},
+			To:             models.AchievementStatusRevisionRequested,
+			Permission:     models.PermAchievementRequestRevision,
+			Actor:          ActorReviewer,
+			RequiredInputs: []string{"comments"},
+			Note:           "Dosen meminta revisi",
+		},
+		{
+			Name:           ActionReject,
+			From:           []string{models.AchievementStatusSubmitted, models.AchievementStatusPartiallyApproved},
+			To:             models.AchievementStatusRejected,
+			Permission:     models.PermAchievementReject,
+			Actor:          ActorReviewer,
+			RequiredInputs: []string{"reason"},
+			Note:           "Ditolak",
+		},
+	},
+}
+
+func (w *Workflow) Action(name string) (*WorkflowAction, bool) {
+	for i := range w.Actions {
+		if w.Actions[i].Name == name {
+			return &w.Actions[i], true
+		}
+	}
+	return nil, false
+}
+
+func (a *WorkflowAction) allowedFrom(status string) bool {
+	for _, s := range a.From {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// workflowError membawa status HTTP agar handler cukup memanggil respond
+type workflowError struct {
+	Status  int
+	Message string
+}
+
+func (e *workflowError) Error() string { return e.Message }
+
+func (e *workflowError) respond(c *fiber.Ctx) error {
+	return c.Status(e.Status).JSON(fiber.Map{"error": e.Message})
+}
+
+// errStaleStatus: status berubah oleh request lain di antara cek & update
+var errStaleStatus = errors.New("achievement status changed concurrently")
+
+// checkAction memastikan action boleh dilakukan user pada prestasi ini
+func (s *AchievementService) checkAction(claims *utils.JWTClaims, ach *models.Achievement, name string) (*WorkflowAction, *workflowError) {
+	action, ok := achievementWorkflow.Action(name)
+	if !ok {
+		return nil, &workflowError{400, "unknown action: " + name}
+	}
+	if !claims.HasPermission(action.Permission) {
+		return nil, &workflowError{403, "Forbidden: missing permission " + action.Permission}
+	}
+	if !s.isActor(claims, ach, action.Actor) {
+		return nil, &workflowError{403, "Forbidden: you are not allowed to " + name + " this achievement"}
+	}
+	if !action.allowedFrom(ach.Status) {
+		return nil, &workflowError{409, fmt.Sprintf("action '%s' is not allowed when status is '%s'", name, ach.Status)}
+	}
+	if action.Stage != "" && (action.Stage == StageFinal) != s.isLastStage(ach) {
+		return nil, &workflowError{409, fmt.Sprintf("action '%s' is not allowed at the current approval stage", name)}
+	}
+	return action, nil
+}
+
+func (s *AchievementService) isActor(claims *utils.JWTClaims, ach *models.Achievement, actor string) bool {
+	switch actor {
+	case ActorOwner:
+		me, err := s.AdminRepo.GetStudentByUserID(claims.ID)
+		return err == nil && me.StudentID == ach.StudentID
+	case ActorReviewer:
+		_, stage := s.currentStage(ach)
+		return s.isStageApprover(claims, ach, stage)
+	}
+	return false
+}
+
+// readInputs mengambil RequiredInputs dari body JSON
+func (a *WorkflowAction) readInputs(c *fiber.Ctx) (map[string]string, *workflowError) {
+	inputs := map[string]string{}
+	if len(a.RequiredInputs) == 0 {
+		return inputs, nil
+	}
+
+	var body map[string]interface{}
+	if err := c.BodyParser(&body); err != nil {
+		return nil, &workflowError{400, "invalid input"}
+	}
+	var missing []string
+	for _, key := range a.RequiredInputs {
+		switch v := body[key].(type) {
+		case string:
+			if v = strings.TrimSpace(v); v != "" {
+				inputs[key] = v
+				continue
+			}
+		case []interface{}:
+			// input terstruktur (misal daftar komentar) dibaca handler sendiri
+			if len(v) > 0 {
+				continue
+			}
+		}
+		missing = append(missing, key)
+	}
+	if len(missing) > 0 {
+		return nil, &workflowError{400, "required input: " + strings.Join(missing, ", ")}
+	}
+	return inputs, nil
+}
+
+// historyEntry membuat catatan history untuk action (status = status setelah action)
+func (a *WorkflowAction) historyEntry(claims *utils.JWTClaims, current string, inputs map[string]string, at time.Time) models.AchievementHistory {
+	status := a.To
+	if status == "" {
+		status = current
+	}
+	notes := a.Note
+	if reason := inputs["reason"]; reason != "" {
+		notes += ": " + reason
+	}
+	return models.AchievementHistory{
+		Status:    status,
+		ChangedBy: claims.Username,
+		ChangedAt: at,
+		Notes:     notes,
+	}
+}
+
+// transition menjalankan action yang mengubah status: MongoDB (status +
+// history) lalu sinkronisasi achievement_references di PostgreSQL, atau
+// extra.Sync jika keduanya harus ditulis bersama
+func (s *AchievementService) transition(ctx context.Context, claims *utils.JWTClaims, ach *models.Achievement,
+	action *WorkflowAction, inputs map[string]string, extra *transitionExtra) error {
+	now := time.Now()
+	set := bson.M{"status": action.To, "updatedAt": now}
+	entry := action.historyEntry(claims, ach.Status, inputs, now)
+	if extra != nil && extra.Note != "" {
+		entry.Notes += " " + extra.Note
+	}
+	push := bson.M{"history": entry}
+	if extra != nil {
+		for k, v := range extra.Set {
+			set[k] = v
+		}
+		for k, v := range extra.Push {
+			push[k] = v
+		}
+	}
+	update := bson.M{"$set": set, "$push": push}
+
+	var match bson.M
+	if extra != nil {
+		match = extra.Match
+	}
+	updateMongo := func() error {
+		ok, err := s.MongoRepo.UpdateIfStatus(ctx, ach.ID, ach.Status, match, update)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return errStaleStatus
+		}
+		return nil
+	}
+
+	var err error
+	mongoID := ach.ID.Hex()
+	if extra != nil && extra.Sync != nil {
+		err = extra.Sync(ctx, action, updateMongo)
+	} else if err = updateMongo(); err != nil {
+		return err
+	} else {
+		err = s.syncReference(ctx, claims, action, mongoID, inputs)
+	}
+	if err != nil {
+		if !errors.Is(err, errStaleStatus) {
+			log.Printf("Postgres Sync Error (%s %s): %v", action.Name, mongoID, err)
+		}
+		return err
+	}
+
+	ach.Status = action.To
+	if extra != nil {
+		extra.apply(ach)
+	}
+	if action.To == models.AchievementStatusVerified {
+		s.scoreAchievement(ctx, ach)
+	}
+	return nil
+}
+
+// syncReference menyamakan status achievement_references dengan MongoDB
+func (s *AchievementService) syncReference(ctx context.Context, claims *utils.JWTClaims, action *WorkflowAction,
+	mongoID string, inputs map[string]string) error {
+	var err error
+	switch action.To {
+	case models.AchievementStatusSubmitted:
+		err = s.PgRepo.UpdateToSubmitted(ctx, mongoID)
+	case models.AchievementStatusVerified:
+		err = s.PgRepo.UpdateToVerified(ctx, mongoID, claims.ID)
+	case models.AchievementStatusRejected:
+		err = s.PgRepo.UpdateToRejected(ctx, mongoID, claims.ID, inputs["reason"])
+	default:
+		err = s.PgRepo.UpdateStatus(ctx, mongoID, action.To)
+	}
+	return err
+}
+
+// transitionExtra: perubahan dokumen tambahan yang disimpan atomik bersama transisi
+type transitionExtra struct {
+	Set  bson.M
+	Push bson.M
+	// syarat tambahan filter update (misal approvalStage yang sedang berjalan)
+	Match bson.M
+	// ditambahkan ke catatan history
+	Note string
+	// dipanggil setelah update berhasil untuk menyamakan struct di memori
+	Apply func(ach *models.Achievement)
+	// Sync menggantikan sinkronisasi PostgreSQL bawaan; update (langkah
+	// MongoDB) dijalankan di dalamnya sebelum transaksi PostgreSQL di-commit
+	Sync func(ctx context.Context, action *WorkflowAction, update func() error) error
+}
+
+func (e *transitionExtra) apply(ach *models.Achievement) {
+	if e.Apply != nil {
+		e.Apply(ach)
+	}
+}
+
+// transitionPrepare menyiapkan transitionExtra dari body request (boleh nil)
+type transitionPrepare func(c *fiber.Ctx, claims *utils.JWTClaims, ach *models.Achievement) (*transitionExtra, *workflowError)
+
+// runTransition: alur lengkap handler yang hanya memindahkan status
+func (s *AchievementService) runTransition(c *fiber.Ctx, name string) (*models.Achievement, *WorkflowAction, map[string]string, error) {
+	return s.runTransitionWith(c, name, nil)
+}
+
+func (s *AchievementService) runTransitionWith(c *fiber.Ctx, name string, prepare transitionPrepare) (*models.Achievement, *WorkflowAction, map[string]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	claims := c.Locals("claims").(*utils.JWTClaims)
+
+	ach, err := s.MongoRepo.GetByID(ctx, c.Params("id"))
+	if err != nil {
+		return nil, nil, nil, &workflowError{404, "Prestasi tidak ditemukan"}
+	}
+
+	action, werr := s.checkAction(claims, ach, name)
+	if werr != nil {
+		return nil, nil, nil, werr
+	}
+	inputs, werr := action.readInputs(c)
+	if werr != nil {
+		return nil, nil, nil, werr
+	}
+
+	var extra *transitionExtra
+	if prepare != nil {
+		if extra, werr = prepare(c, claims, ach); werr != nil {
+			return nil, nil, nil, werr
+		}
+	}
+
+	if err := s.transition(ctx, claims, ach, action, inputs, extra); err != nil {
+		if errors.Is(err, errStaleStatus) {
+			return nil, nil, nil, &workflowError{409, "achievement status changed, reload and try again"}
+		}
+		return nil, nil, nil, &workflowError{500, "Gagal memperbarui status prestasi"}
+	}
+	return ach, action, inputs, nil
+}
+
+// respondWorkflowError mengubah error dari runTransition menjadi response
+func respondWorkflowError(c *fiber.Ctx, err error) error {
+	var werr *workflowError
+	if errors.As(err, &werr) {
+		return werr.respond(c)
+	}
+	return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+}
+
+// GET /api/v1/achievements/:id/actions
+// Action yang boleh dilakukan user yang login pada prestasi ini
+func (s *AchievementService) Actions(c *fiber.Ctx) error {
+	claims := c.Locals("claims").(*utils.JWTClaims)
+
+	ach, err := s.MongoRepo.GetByID(context.Background(), c.Params("id"))
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Prestasi tidak ditemukan"})
+	}
+	if !s.canRead(claims, ach.StudentID) {
+		return c.Status(403).JSON(fiber.Map{"error": "Akses Ditolak: Anda tidak berhak melihat prestasi ini"})
+	}
+
+	actions := []fiber.Map{}
+	for _, a := range achievementWorkflow.Actions {
+		if _, werr := s.checkAction(claims, ach, a.Name); werr != nil {
+			continue
+		}
+		inputs := a.RequiredInputs
+		if len(inputs) == 0 {
+			inputs = []string{}
+		}
+		item := fiber.Map{"action": a.Name, "required_inputs": inputs}
+		if a.To != "" {
+			item["to_status"] = a.To
+		}
+		actions = append(actions, item)
+	}
+
+	return c.JSON(fiber.Map{
+		"id":      ach.ID.Hex(),
+		"status":  ach.Status,
+		"actions": actions,
+	})
+}
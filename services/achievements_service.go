@@ -3,6 +3,7 @@ package services
 import (
 	"context"
 	"fmt"
+	"os"
 	"time"
 	"log"
 	"achievements-uas/app/models"
@@ -19,6 +20,13 @@ type AchievementService struct {
 	MongoRepo *repository.AchievementMongoRepository
 	PgRepo    *repository.AchievementPostgresRepository
 	AdminRepo *repository.AdminRepository
+	// rulebook poin, dipakai saat prestasi menjadi 'verified'
+	ScoringRepo *repository.ScoringRuleRepository
+	// thread diskusi per prestasi + notifikasi mention
+	CommentRepo *repository.AchievementCommentRepository
+	Mailer      utils.Mailer
+	// verifikasi bertingkat per jenis / tingkat prestasi
+	ApprovalRepo *repository.ApprovalChainRepository
 }
 
 // GET /api/v1/achievements
@@ -27,8 +35,8 @@ func (s *AchievementService) List(c *fiber.Ctx) error {
     ctx := context.Background()
     claims := c.Locals("claims").(*utils.JWTClaims)
 
-    // CASE 1: ADMIN (Lihat Semua)
-    if claims.Role == "Admin" {
+    // CASE 1: READ ALL (Lihat Semua)
+    if claims.HasPermission(models.PermAchievementReadAll) {
         refs, total, err := s.PgRepo.GetAllWithCount(ctx, c.Query("status"), "", c.QueryInt("limit", 10), c.QueryInt("offset", 0))
         if err != nil {
             return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch references"})
@@ -36,8 +44,8 @@ func (s *AchievementService) List(c *fiber.Ctx) error {
         return s.fetchFullDataFromMongo(c, refs, total)
     }
 
-    // CASE 2: DOSEN WALI (Hanya bimbingan sendiri)
-    if claims.Role == "Dosen Wali" {
+    // CASE 2: READ ADVISEE / Dosen Wali (Hanya bimbingan sendiri)
+    if claims.HasPermission(models.PermAchievementReadAdvisee) {
         // Ambil bimbingan berdasarkan UUID Dosen (advisor_id di DB)
         advisees, err := s.AdminRepo.GetLecturerAdvisees(claims.ID)
         if err != nil {
@@ -60,8 +68,8 @@ func (s *AchievementService) List(c *fiber.Ctx) error {
         return s.fetchFullDataFromMongo(c, refs, len(refs))
     }
 
-    // CASE 3: MAHASISWA (Milik sendiri)
-    if claims.Role == "Mahasiswa" {
+    // CASE 3: READ OWN / Mahasiswa (Milik sendiri)
+    if claims.HasPermission(models.PermAchievementReadOwn) {
         // Ambil profil untuk dapat NIM-nya agar bisa query ke Mongo
         student, err := s.AdminRepo.GetStudentByUserID(claims.ID)
         if err != nil {
@@ -89,33 +97,38 @@ func (s *AchievementService) Detail(c *fiber.Ctx) error {
         return c.Status(404).JSON(fiber.Map{"error": "Prestasi tidak ditemukan"})
     }
 
-    // 2. CEK STUDENT ID (Cross-check Kepemilikan)
-    if claims.Role == "Mahasiswa" {
-        // Ambil profil mahasiswa dari Postgres berdasarkan UserID di JWT
-        me, err := s.AdminRepo.GetStudentByUserID(claims.ID)
-        if err != nil {
-            return c.Status(403).JSON(fiber.Map{"error": "Profil mahasiswa tidak valid"})
-        }
+    // 2. CEK AKSES (read_all / bimbingan / milik sendiri)
+    if !s.canRead(claims, data.StudentID) {
+        return c.Status(403).JSON(fiber.Map{
+            "error": "Akses Ditolak: Anda tidak berhak melihat detail prestasi ini",
+        })
+    }
+
+    return c.JSON(data)
+}
+
+// canRead: read_all boleh semua, read_advisee hanya mahasiswa bimbingan,
+// read_own hanya prestasi dengan NIM milik user yang login
+func (s *AchievementService) canRead(claims *utils.JWTClaims, studentNIM string) bool {
+    if claims.HasPermission(models.PermAchievementReadAll) {
+        return true
+    }
 
-        // VALIDASI: Apakah StudentID di MongoDB sama dengan NIM mahasiswa yang login?
-        if data.StudentID != me.StudentID {
-            // Jika beda, berarti dia mencoba melihat prestasi orang lain
-            return c.Status(403).JSON(fiber.Map{
-                "error": "Akses Ditolak: Anda hanya boleh melihat detail prestasi milik sendiri!",
-            })
+    if claims.HasPermission(models.PermAchievementReadAdvisee) {
+        isAdvisee, err := s.AdminRepo.CheckIsAdvisee(studentNIM, claims.ID)
+        if err == nil && isAdvisee {
+            return true
         }
     }
 
-    // 3. CEK UNTUK DOSEN WALI (Filter Bimbingan)
-    if claims.Role == "Dosen Wali" {
-        // Pastikan mahasiswa pemilik prestasi ini adalah anak bimbingannya
-        isAdvisee, err := s.AdminRepo.CheckIsAdvisee(data.StudentID, claims.ID)
-        if err != nil || !isAdvisee {
-            return c.Status(403).JSON(fiber.Map{"error": "Akses Ditolak: Ini bukan mahasiswa bimbingan anda"})
+    if claims.HasPermission(models.PermAchievementReadOwn) {
+        me, err := s.AdminRepo.GetStudentByUserID(claims.ID)
+        if err == nil && me.StudentID == studentNIM {
+            return true
         }
     }
 
-    return c.JSON(data)
+    return false
 }
 
 // POST /api/v1/achievements
@@ -135,8 +148,9 @@ func (s *AchievementService) Create(c *fiber.Ctx) error {
     }
 
     ach.StudentID = student.StudentID
-    ach.Status = "draft"
+    ach.Status = achievementWorkflow.Initial
     ach.Points = 0 
+    ach.ScoringRuleID = "" // poin hanya diisi rulebook saat verifikasi
     ach.CreatedAt = time.Now()
     ach.UpdatedAt = time.Now()
     
@@ -144,7 +158,7 @@ func (s *AchievementService) Create(c *fiber.Ctx) error {
     ach.History = []models.AchievementHistory{
         {
             StudentID: student.StudentID,
-            Status:    "draft",
+            Status:    achievementWorkflow.Initial,
             ChangedBy: claims.Username, 
             ChangedAt: time.Now(),
             Notes:     "Initial draft created",
@@ -160,7 +174,7 @@ func (s *AchievementService) Create(c *fiber.Ctx) error {
         ID:                 uuid.New().String(),
         StudentID:          student.ID,
         MongoAchievementID: mongoData.ID.Hex(),
-        Status:             "draft",
+        Status:             achievementWorkflow.Initial,
         CreatedAt:          time.Now(),
         UpdatedAt:          time.Now(),
     }
@@ -173,119 +187,74 @@ func (s *AchievementService) Create(c *fiber.Ctx) error {
     return c.Status(201).JSON(mongoData)
 }
 
+// PUT /api/v1/achievements/:id
 func (s *AchievementService) Update(c *fiber.Ctx) error {
-    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-    defer cancel()
-    claims := c.Locals("claims").(*utils.JWTClaims)
-
-    idParam := c.Params("id")
-    oid, err := primitive.ObjectIDFromHex(idParam)
-    if err != nil {
-        return c.Status(400).JSON(fiber.Map{"error": "Format ID tidak valid"})
-    }
-
-    // 1. Ambil data lama dari MongoDB
-    oldData, err := s.MongoRepo.GetByID(ctx, idParam)
-    if err != nil {
-        return c.Status(404).JSON(fiber.Map{"error": "Prestasi tidak ditemukan"})
-    }
-
-    // --- PENGUNCI STATUS: HANYA DRAFT ---
-    if oldData.Status != "draft" {
-        return c.Status(403).JSON(fiber.Map{
-            "error": "Akses ditolak",
-            "message": "Hanya prestasi dengan status 'draft' yang dapat diubah",
-        })
-    }
-
-    var input models.Achievement
-    if err := c.BodyParser(&input); err != nil {
-        return c.Status(400).JSON(fiber.Map{"error": "Data input tidak valid"})
-    }
-
-    // 2. Update di MongoDB
-    now := time.Now()
-    updateQuery := bson.M{
-        "$set": bson.M{
-            "achievementType": input.AchievementType,
-            "title":           input.Title,
-            "description":     input.Description,
-            "details":         input.Details,
-            "tags":            input.Tags,
-            "updatedAt":       now,
-        },
-        "$push": bson.M{
-            "history": models.AchievementHistory{
-                Status:    "draft",
-                ChangedBy: claims.Username,
-                ChangedAt: now,
-                Notes:     "Melakukan perubahan data prestasi",
-            },
-        },
-    }
-
-    err = s.MongoRepo.Update(ctx, oid, updateQuery)
-    if err != nil {
-        return c.Status(500).JSON(fiber.Map{"error": "Gagal update di MongoDB"})
-    }
-
-    // 3. Update di PostgreSQL (Sinkronisasi Timestamp)
-    // Supaya di dashboard dosen, data ini naik ke urutan paling atas karena baru saja diupdate
-    err = s.PgRepo.UpdateTimestamp(ctx, idParam)
-    if err != nil {
-        // Kita log saja, jangan gagalkan response karena data utama sudah aman di Mongo
-        log.Printf("Warning: Gagal sinkronisasi timestamp ke Postgres untuk ID %s: %v", idParam, err)
-    }
-
-    return c.JSON(fiber.Map{
-        "message": "Prestasi berhasil diperbarui",
-        "id":      idParam,
-    })
-}
-
-// DELETE /api/v1/achievements/:id
-func (s *AchievementService) Delete(c *fiber.Ctx) error {
-	ctx := context.Background()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
 	claims := c.Locals("claims").(*utils.JWTClaims)
 	idParam := c.Params("id")
 
-	// 1. Cari data di MongoDB untuk cek status & kepemilikan
-	achievement, err := s.MongoRepo.GetByID(ctx, idParam)
+	// 1. Ambil data lama dari MongoDB
+	oldData, err := s.MongoRepo.GetByID(ctx, idParam)
 	if err != nil {
-		return c.Status(404).JSON(fiber.Map{"error": "Achievement not found"})
+		return c.Status(404).JSON(fiber.Map{"error": "Prestasi tidak ditemukan"})
 	}
 
-	if achievement.Status == "deleted" {
-        return c.Status(400).JSON(fiber.Map{"error": "Cannot update a deleted achievement"})
-    }
+	// --- PENGUNCI STATUS: lewat workflow ---
+	action, werr := s.checkAction(claims, oldData, ActionUpdate)
+	if werr != nil {
+		return werr.respond(c)
+	}
 
-	// 2. Precondition: Hanya status 'draft' yang boleh dihapus
-	if achievement.Status != "draft" {
-		return c.Status(400).JSON(fiber.Map{"error": "Only draft achievements can be deleted"})
+	var input models.Achievement
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Data input tidak valid"})
 	}
 
-	// 3. Pastikan yang menghapus adalah pemiliknya (Mahasiswa yang login)
-	student, _ := s.AdminRepo.GetStudentByUserID(claims.ID)
-	if achievement.StudentID != student.StudentID {
-		return c.Status(403).JSON(fiber.Map{"error": "You are not authorized to delete this data"})
+	// 2. Update di MongoDB (hanya jika status belum berubah sejak dicek)
+	now := time.Now()
+	updateQuery := bson.M{
+		"$set": bson.M{
+			"achievementType": input.AchievementType,
+			"title":           input.Title,
+			"description":     input.Description,
+			"details":         input.Details,
+			"tags":            input.Tags,
+			"updatedAt":       now,
+		},
+		"$push": bson.M{
+			"history": action.historyEntry(claims, oldData.Status, nil, now),
+		},
 	}
 
-	// 4. FLOW 1: Soft delete data di MongoDB (Ubah status jadi 'deleted')
-	oid, _ := primitive.ObjectIDFromHex(idParam)
-	err = s.MongoRepo.SoftDelete(ctx, oid)
+	ok, err := s.MongoRepo.UpdateIfStatus(ctx, oldData.ID, oldData.Status, nil, updateQuery)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to soft delete in MongoDB"})
+		return c.Status(500).JSON(fiber.Map{"error": "Gagal update di MongoDB"})
+	}
+	if !ok {
+		return c.Status(409).JSON(fiber.Map{"error": "achievement status changed, reload and try again"})
 	}
 
-	// 5. FLOW 2: Update reference di PostgreSQL (Ubah status jadi 'deleted')
-	// Pastikan PgRepo memiliki fungsi UpdateStatus
-	// Di dalam AchievementService.Delete
-	err = s.PgRepo.UpdateStatus(ctx, idParam, "deleted")
-		if err != nil {
-			return c.Status(500).JSON(fiber.Map{"error": "Failed to update Postgres reference"})
-		}
+	// 3. Update di PostgreSQL (Sinkronisasi Timestamp)
+	// Supaya di dashboard dosen, data ini naik ke urutan paling atas karena baru saja diupdate
+	if err := s.PgRepo.UpdateTimestamp(ctx, idParam); err != nil {
+		// Kita log saja, jangan gagalkan response karena data utama sudah aman di Mongo
+		log.Printf("Warning: Gagal sinkronisasi timestamp ke Postgres untuk ID %s: %v", idParam, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Prestasi berhasil diperbarui",
+		"id":      idParam,
+	})
+}
+
+// DELETE /api/v1/achievements/:id
+// Soft delete: status menjadi 'deleted' di MongoDB & PostgreSQL
+func (s *AchievementService) Delete(c *fiber.Ctx) error {
+	if _, _, _, err := s.runTransition(c, ActionDelete); err != nil {
+		return respondWorkflowError(c, err)
+	}
 
-	// 6. FLOW 3: Return success message
 	return c.JSON(fiber.Map{
 		"message": "Achievement draft deleted successfully",
 	})
@@ -294,191 +263,109 @@ func (s *AchievementService) Delete(c *fiber.Ctx) error {
 // POST /api/v1/achievements/:id/submit
 // FR-004: Submit for verification
 func (s *AchievementService) Submit(c *fiber.Ctx) error {
-    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-    defer cancel()
-    claims := c.Locals("claims").(*utils.JWTClaims)
-    mongoID := c.Params("id")
-
-    // 1. Validasi: Pastikan data ada dan statusnya masih 'draft' atau 'rejected'
-    oldData, err := s.MongoRepo.GetByID(ctx, mongoID)
-    if err != nil {
-        return c.Status(404).JSON(fiber.Map{"error": "Achievement not found"})
-    }
-    if oldData.Status != "draft" && oldData.Status != "rejected" {
-        return c.Status(400).JSON(fiber.Map{"error": "Hanya status draft atau rejected yang bisa di-submit"})
-    }
-
-    now := time.Now()
-    
-    // 2. Update MongoDB (Status & History)
-    newHistory := models.AchievementHistory{
-        Status:    "submitted",
-        ChangedBy: claims.Username,
-        ChangedAt: now,
-        Notes:     "Mahasiswa mengajukan verifikasi prestasi",
-    }
-
-    updateQuery := bson.M{
-        "$set":  bson.M{"status": "submitted", "updatedAt": now},
-        "$push": bson.M{"history": newHistory},
-    }
-
-    oid, _ := primitive.ObjectIDFromHex(mongoID)
-    if err := s.MongoRepo.Update(ctx, oid, updateQuery); err != nil {
-        return c.Status(500).JSON(fiber.Map{"error": "Gagal update MongoDB"})
-    }
-
-    // 3. SINKRONISASI KE POSTGRESQL (Mengisi submitted_at)
-    // Kita panggil fungsi khusus agar submitted_at tidak [null] lagi
-    if err := s.PgRepo.UpdateToSubmitted(ctx, mongoID); err != nil {
-        log.Printf("Postgres Sync Error: %v", err)
-    }
+	_, action, _, err := s.runTransitionWith(c, ActionSubmit, s.prepareSubmit)
+	if err != nil {
+		return respondWorkflowError(c, err)
+	}
 
-    return c.JSON(fiber.Map{
-        "message": "Achievement submitted successfully",
-        "status":  "submitted",
-    })
+	return c.JSON(fiber.Map{
+		"message": "Achievement submitted successfully",
+		"status":  action.To,
+	})
 }
 
 // POST /api/v1/achievements/:id/verify
-// FR-007: Verify (Dosen Wali)
+// FR-007: Verify (Dosen Wali), lalu tahap berikutnya sesuai approval chain.
+// Status 'verified' baru tercapai setelah tahap terakhir menyetujui.
 func (s *AchievementService) Verify(c *fiber.Ctx) error {
-    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-    defer cancel()
-    
-    // Ambil data dosen dari JWT (pastikan middleware JWT sudah terpasang)
-    claims := c.Locals("claims").(*utils.JWTClaims)
-    dosenUUID := claims.ID // Ini adalah UUID dosen yang sedang login
-    mongoID := c.Params("id")
-
-    // 1. Ambil data dari Mongo untuk validasi status
-    oldData, err := s.MongoRepo.GetByID(ctx, mongoID)
-    if err != nil {
-        return c.Status(404).JSON(fiber.Map{"error": "Prestasi tidak ditemukan"})
-    }
-
-    // Hanya bisa verifikasi jika statusnya 'submitted'
-    if oldData.Status != "submitted" {
-        return c.Status(400).JSON(fiber.Map{"error": "Hanya prestasi dengan status 'submitted' yang bisa diverifikasi"})
-    }
-
-    now := time.Now()
-
-    // 2. Update status di MongoDB & Tambah History
-    newHistory := models.AchievementHistory{
-        Status:    "verified",
-        ChangedBy: claims.Username, // Nama dosen
-        ChangedAt: now,
-        Notes:     "Prestasi telah diverifikasi dan disetujui oleh Dosen Wali",
-    }
-
-    updateQuery := bson.M{
-        "$set":  bson.M{"status": "verified", "updatedAt": now},
-        "$push": bson.M{"history": newHistory},
-    }
-
-    oid, _ := primitive.ObjectIDFromHex(mongoID)
-    if err := s.MongoRepo.Update(ctx, oid, updateQuery); err != nil {
-        return c.Status(500).JSON(fiber.Map{"error": "Gagal update data di MongoDB"})
-    }
+	claims := c.Locals("claims").(*utils.JWTClaims)
 
-    // 3. SINKRONISASI KE POSTGRESQL
-    // Mengupdate status, verified_at, dan verified_by (ID Dosen)
-    if err := s.PgRepo.UpdateToVerified(ctx, mongoID, dosenUUID); err != nil {
-        log.Printf("Postgres Sync Error: %v", err)
-        return c.Status(500).JSON(fiber.Map{"error": "Gagal sinkronisasi data ke PostgreSQL"})
-    }
+	current, err := s.MongoRepo.GetByID(context.Background(), c.Params("id"))
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Prestasi tidak ditemukan"})
+	}
+	name := ActionApprove
+	if s.isLastStage(current) {
+		name = ActionVerify
+	}
 
-    return c.JSON(fiber.Map{
-        "message": "Prestasi berhasil diverifikasi",
-        "status":  "verified",
-        "verified_by": dosenUUID,
-    })
+	ach, action, _, err := s.runTransitionWith(c, name, s.prepareApproval)
+	if err != nil {
+		return respondWorkflowError(c, err)
+	}
+	approval := latestApproval(ach)
+
+	if action.To != models.AchievementStatusVerified {
+		return c.JSON(fiber.Map{
+			"message":     "Tahap persetujuan berhasil disetujui",
+			"status":      action.To,
+			"approved_by": claims.ID,
+			"stage":       approval,
+		})
+	}
+	return c.JSON(fiber.Map{
+		"message":         "Prestasi berhasil diverifikasi",
+		"status":          action.To,
+		"verified_by":     claims.ID,
+		"stage":           approval,
+		"points":          ach.Points,
+		"scoring_rule_id": ach.ScoringRuleID,
+	})
 }
 
 // POST /api/v1/achievements/:id/reject
-// FR-008: Reject (Dosen Wali)
+// FR-008: Reject (Dosen Wali), body: {"reason": "..."}
 func (s *AchievementService) Reject(c *fiber.Ctx) error {
-    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-    defer cancel()
-    
-    claims := c.Locals("claims").(*utils.JWTClaims)
-    mongoID := c.Params("id")
-
-    // 1. Ambil alasan penolakan dari body JSON
-    var input struct {
-        Reason string `json:"reason"`
-    }
-    if err := c.BodyParser(&input); err != nil || input.Reason == "" {
-        return c.Status(400).JSON(fiber.Map{"error": "Alasan penolakan (reason) wajib diisi"})
-    }
-
-    // 2. Validasi: Cek keberadaan data di MongoDB
-    oldData, err := s.MongoRepo.GetByID(ctx, mongoID)
-    if err != nil {
-        return c.Status(404).JSON(fiber.Map{"error": "Prestasi tidak ditemukan"})
-    }
-
-    // Hanya bisa reject jika statusnya 'submitted' (sedang diajukan)
-    if oldData.Status != "submitted" {
-        return c.Status(400).JSON(fiber.Map{"error": "Hanya prestasi dengan status 'submitted' yang bisa ditolak"})
-    }
-
-    now := time.Now()
-
-    // 3. Update MongoDB: Set status ke 'rejected' dan tambah History
-    newHistory := models.AchievementHistory{
-        Status:    "rejected",
-        ChangedBy: claims.Username, // Nama Dosen/Admin
-        ChangedAt: now,
-        Notes:     "Ditolak: " + input.Reason,
-    }
-
-    updateQuery := bson.M{
-        "$set":  bson.M{"status": "rejected", "updatedAt": now},
-        "$push": bson.M{"history": newHistory},
-    }
-
-    oid, _ := primitive.ObjectIDFromHex(mongoID)
-    if err := s.MongoRepo.Update(ctx, oid, updateQuery); err != nil {
-        return c.Status(500).JSON(fiber.Map{"error": "Gagal update status di MongoDB"})
-    }
+	claims := c.Locals("claims").(*utils.JWTClaims)
 
-    // 4. Update PostgreSQL: Mengisi rejection_note, verified_by, dan status
-    // Query ini memastikan kolom [null] di gambar kamu terisi sesuai data dosen
-    err = s.PgRepo.UpdateToRejected(ctx, mongoID, claims.ID, input.Reason)
-    if err != nil {
-        log.Printf("Postgres Sync Error: %v", err)
-        return c.Status(500).JSON(fiber.Map{"error": "Gagal sinkronisasi data ke PostgreSQL"})
-    }
+	_, action, inputs, err := s.runTransition(c, ActionReject)
+	if err != nil {
+		return respondWorkflowError(c, err)
+	}
 
-    return c.JSON(fiber.Map{
-        "message":        "Prestasi berhasil ditolak",
-        "status":         "rejected",
-        "rejection_note": input.Reason,
-        "rejected_by":    claims.ID,
-    })
+	return c.JSON(fiber.Map{
+		"message":        "Prestasi berhasil ditolak",
+		"status":         action.To,
+		"rejection_note": inputs["reason"],
+		"rejected_by":    claims.ID,
+	})
 }
 
 // GET /api/v1/achievements/:id/history
 func (s *AchievementService) History(c *fiber.Ctx) error {
     ctx := context.Background()
     
+    claims := c.Locals("claims").(*utils.JWTClaims)
+    
     // Ambil data utuh
     data, err := s.MongoRepo.GetByID(ctx, c.Params("id"))
     if err != nil {
         return c.Status(404).JSON(fiber.Map{"error": "Achievement not found"})
     }
 
+    // History memuat komentar, jadi aturan aksesnya sama dengan Detail
+    if !s.canRead(claims, data.StudentID) {
+        return c.Status(403).JSON(fiber.Map{"error": "Akses Ditolak: Anda tidak berhak melihat prestasi ini"})
+    }
+
     // Pastikan jika history masih kosong (nil), return array kosong [] bukan null
     historyList := data.History
     if historyList == nil {
         historyList = []models.AchievementHistory{}
     }
 
+    comments := []models.AchievementComment{}
+    if s.CommentRepo != nil {
+        comments, err = s.CommentRepo.FindByAchievement(ctx, data.ID.Hex())
+        if err != nil {
+            return c.Status(500).JSON(fiber.Map{"error": "failed get comments"})
+        }
+    }
+
     return c.JSON(fiber.Map{
-        "id":      c.Params("id"),
-        "history": historyList,
+        "id":       c.Params("id"),
+        "history":  historyList,
+        "comments": comments,
     })
 }
 
@@ -494,14 +381,15 @@ func (s *AchievementService) UploadAttachment(c *fiber.Ctx) error {
         return c.Status(400).JSON(fiber.Map{"error": "Invalid ID format"})
     }
 
-    // 1. CEK STATUS TERLEBIH DAHULU
-    // Pastikan hanya status 'draft' yang bisa tambah lampiran
+    // 1. CEK STATUS & PEMILIK lewat workflow
     oldData, err := s.MongoRepo.GetByID(ctx, idParam)
     if err != nil {
         return c.Status(404).JSON(fiber.Map{"error": "Achievement not found"})
     }
-    if oldData.Status != "draft" {
-        return c.Status(403).JSON(fiber.Map{"error": "Cannot add attachment to locked achievement"})
+    claims := c.Locals("claims").(*utils.JWTClaims)
+    action, werr := s.checkAction(claims, oldData, ActionAttach)
+    if werr != nil {
+        return werr.respond(c)
     }
 
     // 2. AMBIL FILE
@@ -530,9 +418,14 @@ func (s *AchievementService) UploadAttachment(c *fiber.Ctx) error {
         UploadedAt: time.Now(),
     }
 
-    // 5. UPDATE MONGODB
-    if err := s.MongoRepo.AddAttachment(ctx, oid, att); err != nil {
-        return c.Status(500).JSON(fiber.Map{"error": "Failed to update database"})
+    // 5. UPDATE MONGODB (hanya jika status masih boleh menerima lampiran)
+    added, err := s.MongoRepo.AddAttachment(ctx, oid, action.From, att)
+    if err != nil || !added {
+        os.Remove(path)
+        if err != nil {
+            return c.Status(500).JSON(fiber.Map{"error": "Failed to update database"})
+        }
+        return c.Status(409).JSON(fiber.Map{"error": "achievement status changed, reload and try again"})
     }
 
     // 6. UPDATE POSTGRES (Sinkronkan UpdatedAt)
@@ -2,12 +2,14 @@ package services
 
 import (
 	"context"
+	"errors"
 	"strconv"
 	"time"
 	"achievements-uas/app/models"
 	"achievements-uas/app/repository"
 	"achievements-uas/utils"
 	"fmt"
+	"log"
 	
 
 	"github.com/gofiber/fiber/v2"
@@ -23,6 +25,9 @@ type UserAdminService struct {
 	// ===== FR-010 =====
 	AchPgRepo    *repository.AchievementPostgresRepository
 	AchMongoRepo *repository.AchievementMongoRepository
+
+	Passwords   *PasswordService
+	Invitations *InvitationService
 }
 
 // ==============================================
@@ -34,6 +39,8 @@ func NewAdminService(
 	rolePermRepo *repository.RolePermissionRepository,
 	achPgRepo *repository.AchievementPostgresRepository,
 	achMongoRepo *repository.AchievementMongoRepository,
+	passwords *PasswordService,
+	invitations *InvitationService,
 ) *UserAdminService {
 	return &UserAdminService{
 		AdminRepo:    adminRepo,
@@ -41,7 +48,45 @@ func NewAdminService(
 		RolePermRepo: rolePermRepo,
 		AchPgRepo:    achPgRepo,
 		AchMongoRepo: achMongoRepo,
+		Passwords:    passwords,
+		Invitations:  invitations,
+	}
+}
+
+// checkProfileForRole mencocokkan profil yang dikirim dengan permission role:
+// read_own = mahasiswa (wajib student_id), read_advisee = dosen (wajib
+// lecturer_id). Role dengan read_all (misal Admin) tidak wajib punya profil.
+// true = response 400 sudah ditulis.
+func (s *UserAdminService) checkProfileForRole(c *fiber.Ctx, roleID, studentID, lecturerID string) (bool, error) {
+	perms, err := s.RolePermRepo.GetPermissionsByRole(roleID)
+	if err != nil {
+		return true, c.Status(500).JSON(fiber.Map{"error": "failed get role permissions"})
 	}
+	has := map[string]bool{}
+	for _, p := range perms {
+		has[p] = true
+	}
+	studentRole := has[models.PermAchievementReadOwn]
+	lecturerRole := has[models.PermAchievementReadAdvisee]
+	privileged := has[models.PermAchievementReadAll]
+
+	switch {
+	case studentID != "" && lecturerID != "":
+		return true, c.Status(400).JSON(fiber.Map{"error": "student_id and lecturer_id cannot be used together"})
+	case studentID != "" && !studentRole:
+		return true, c.Status(400).JSON(fiber.Map{"error": "role does not allow a student profile"})
+	case lecturerID != "" && !lecturerRole:
+		return true, c.Status(400).JSON(fiber.Map{"error": "role does not allow a lecturer profile"})
+	case privileged || (studentID == "" && lecturerID == "" && !studentRole && !lecturerRole):
+		return false, nil
+	case studentID == "" && lecturerID == "" && studentRole && lecturerRole:
+		return true, c.Status(400).JSON(fiber.Map{"error": "student_id or lecturer_id is required for this role"})
+	case studentID == "" && studentRole && !lecturerRole:
+		return true, c.Status(400).JSON(fiber.Map{"error": "student_id is required for this role"})
+	case lecturerID == "" && lecturerRole && !studentRole:
+		return true, c.Status(400).JSON(fiber.Map{"error": "lecturer_id is required for this role"})
+	}
+	return false, nil
 }
 
 func (s *UserAdminService) Create(c *fiber.Ctx) error {
@@ -58,18 +103,48 @@ func (s *UserAdminService) Create(c *fiber.Ctx) error {
         // Field Dosen [cite: 101]
         LecturerID   string `json:"lecturer_id"`
         Department   string `json:"department"`
+        // true = user dibuat nonaktif & menerima link aktivasi lewat email
+        Invite       bool   `json:"invite"`
     }
 
     if err := c.BodyParser(&body); err != nil {
         return c.Status(400).JSON(fiber.Map{"error": "invalid input"})
     }
 
-    role, err := s.RoleRepo.FindByID(body.RoleID)
-    if err != nil {
+    if _, err := s.RoleRepo.FindByID(body.RoleID); err != nil {
         return c.Status(400).JSON(fiber.Map{"error": "invalid role_id"})
     }
 
-    hash, _ := utils.HashPassword(body.Password)
+    if rejected, err := s.checkProfileForRole(c, body.RoleID, body.StudentID, body.LecturerID); rejected {
+        return err
+    }
+
+    // semua identifier login (username, email, NIM, NIDN) harus unik lintas user
+    if taken, err := s.checkIdentifiers(c, "", body.Username, body.Email, body.StudentID, body.LecturerID); taken {
+        return err
+    }
+
+    var err error
+
+    // Mode undangan: admin tidak memilih password, user mengaktifkan akunnya sendiri
+    password := body.Password
+    if body.Invite {
+        if body.Email == "" {
+            return c.Status(400).JSON(fiber.Map{"error": "email is required for invitation"})
+        }
+        // password acak yang tidak pernah dikirim ke siapa pun
+        if password, _, err = utils.GenerateOneTimeToken(); err != nil {
+            return c.Status(500).JSON(fiber.Map{"error": "failed generate password"})
+        }
+    } else if err := s.Passwords.Validate("", body.Password,
+        body.Username, body.Email, body.FullName, body.StudentID, body.LecturerID); err != nil {
+        return policyErrorResponse(c, err)
+    }
+
+    hash, err := utils.HashPassword(password)
+    if err != nil {
+        return c.Status(500).JSON(fiber.Map{"error": "failed hash password"})
+    }
     userID := uuid.New().String()
 
     // Data User Dasar [cite: 33-36]
@@ -80,20 +155,16 @@ func (s *UserAdminService) Create(c *fiber.Ctx) error {
         PasswordHash: hash,
         FullName:     body.FullName,
         RoleID:       body.RoleID,
-        IsActive:     true,
+        IsActive:     !body.Invite,
         CreatedAt:    time.Now(),
         UpdatedAt:    time.Now(),
     }
 
-    // Eksekusi berdasarkan Role [cite: 223-228]
-    switch role.Name {
-    case "Admin":
-        if err := s.AdminRepo.CreateUser(user); err != nil {
-            return c.Status(500).JSON(fiber.Map{"error": "failed to create admin"})
-        }
-        return c.Status(201).JSON(fiber.Map{"status": "success", "data": user})
-
-    case "Mahasiswa":
+    // Profil dipilih dari data yang dikirim, sudah dicocokkan dengan permission
+    // role di checkProfileForRole sehingga role baru tidak butuh perubahan kode [cite: 223-228]
+    var data interface{}
+    switch {
+    case body.StudentID != "":
         student := &models.Student{
             ID:           uuid.New().String(),
             UserID:       userID,
@@ -105,9 +176,9 @@ func (s *UserAdminService) Create(c *fiber.Ctx) error {
         if err := s.AdminRepo.CreateStudent(user, student); err != nil {
             return c.Status(500).JSON(fiber.Map{"error": "failed to create student and user"})
         }
-        return c.Status(201).JSON(fiber.Map{"status": "success", "data": student})
+        data = student
 
-    case "Dosen Wali":
+    case body.LecturerID != "":
         lecturer := &models.Lecturer{
             ID:         uuid.New().String(),
             UserID:     userID,
@@ -118,11 +189,53 @@ func (s *UserAdminService) Create(c *fiber.Ctx) error {
         if err := s.AdminRepo.CreateLecturer(user, lecturer); err != nil {
             return c.Status(500).JSON(fiber.Map{"error": "failed to create lecturer and user"})
         }
-        return c.Status(201).JSON(fiber.Map{"status": "success", "data": lecturer})
+        data = lecturer
 
     default:
-        return c.Status(400).JSON(fiber.Map{"error": "role not supported"})
+        if err := s.AdminRepo.CreateUser(user); err != nil {
+            return c.Status(500).JSON(fiber.Map{"error": "failed to create user"})
+        }
+        data = user
+    }
+
+    resp := fiber.Map{"status": "success", "data": data}
+    if body.Invite {
+        claims := c.Locals("claims").(*utils.JWTClaims)
+        inv, err := s.Invitations.Send(user, claims.ID)
+        if err != nil {
+            log.Printf("[WARN] send invitation to %s: %v", user.Email, err)
+            resp["invitation"] = fiber.Map{"status": "failed", "error": "user created but invitation could not be sent, use resend"}
+        } else {
+            resp["invitation"] = fiber.Map{"status": models.InvitationPending, "expires_at": inv.ExpiresAt}
+        }
+    } else {
+        s.Passwords.Record(userID, hash)
     }
+    return c.Status(201).JSON(resp)
+}
+
+// checkIdentifiers menulis response 409 dan mengembalikan true jika salah
+// satu identifier sudah dipakai user lain, false jika semuanya bebas.
+func (s *UserAdminService) checkIdentifiers(c *fiber.Ctx, excludeUserID string, values ...string) (bool, error) {
+	seen := map[string]bool{}
+	for _, v := range values {
+		if v == "" {
+			continue
+		}
+		if seen[v] {
+			return true, c.Status(409).JSON(fiber.Map{"error": "identifier used more than once", "identifier": v})
+		}
+		seen[v] = true
+
+		taken, err := s.AdminRepo.IdentifierTaken(v, excludeUserID)
+		if err != nil {
+			return true, c.Status(500).JSON(fiber.Map{"error": "failed check identifier"})
+		}
+		if taken {
+			return true, c.Status(409).JSON(fiber.Map{"error": "identifier already in use", "identifier": v})
+		}
+	}
+	return false, nil
 }
 
 // ==============================================
@@ -159,6 +272,9 @@ func (s *UserAdminService) Update(c *fiber.Ctx) error {
 		FullName string `json:"full_name"`
 		RoleID   string `json:"role_id"`
 		IsActive bool   `json:"is_active"`
+		// opsional: NIM / NIDN baru untuk profil yang sudah ada
+		StudentID  string `json:"student_id"`
+		LecturerID string `json:"lecturer_id"`
 	}
 
 	if err := c.BodyParser(&body); err != nil {
@@ -169,6 +285,10 @@ func (s *UserAdminService) Update(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"error": "invalid role_id"})
 	}
 
+	if taken, err := s.checkIdentifiers(c, id, body.Username, body.Email, body.StudentID, body.LecturerID); taken {
+		return err
+	}
+
 	user := &models.User{
 		ID:       id,
 		Username: body.Username,
@@ -178,7 +298,10 @@ func (s *UserAdminService) Update(c *fiber.Ctx) error {
 		IsActive: body.IsActive,
 	}
 
-	if err := s.AdminRepo.UpdateUser(user); err != nil {
+	if err := s.AdminRepo.UpdateUser(user, body.StudentID, body.LecturerID); err != nil {
+		if errors.Is(err, repository.ErrProfileNotFound) {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
 		return c.Status(500).JSON(fiber.Map{"error": "failed update user"})
 	}
 
@@ -230,6 +353,10 @@ func (s *UserAdminService) UpdatePassword(c *fiber.Ctx) error {
 		})
 	}
 
+	if err := s.Passwords.Validate(userID, body.NewPassword); err != nil {
+		return policyErrorResponse(c, err)
+	}
+
 	// hash password baru
 	hash, err := utils.HashPassword(body.NewPassword)
 	if err != nil {
@@ -244,6 +371,7 @@ func (s *UserAdminService) UpdatePassword(c *fiber.Ctx) error {
 			"error": "failed update password",
 		})
 	}
+	s.Passwords.Record(userID, hash)
 
 	return c.JSON(fiber.Map{
 		"status":  "success",
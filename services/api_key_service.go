@@ -0,0 +1,151 @@
+package services
+
+import (
+	"log"
+	"strings"
+	"time"
+
+	"achievements-uas/app/models"
+	"achievements-uas/app/repository"
+	"achievements-uas/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// =====================================================
+// API KEY ADMINISTRATION
+// =====================================================
+// Key plain hanya dikembalikan sekali saat dibuat. Scope harus permission
+// yang dimiliki pembuatnya dan bukan permission administrasi.
+
+type APIKeyService struct {
+	Repo           *repository.APIKeyRepository
+	PermissionRepo *repository.PermissionRepository
+}
+
+func NewAPIKeyService(repo *repository.APIKeyRepository, permissionRepo *repository.PermissionRepository) *APIKeyService {
+	return &APIKeyService{Repo: repo, PermissionRepo: permissionRepo}
+}
+
+// GET /api/v1/api-keys
+func (s *APIKeyService) GetAll(c *fiber.Ctx) error {
+	keys, err := s.Repo.FindAll()
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed get api keys"})
+	}
+	return c.JSON(fiber.Map{"status": "success", "data": keys})
+}
+
+// GET /api/v1/api-keys/:id
+func (s *APIKeyService) GetByID(c *fiber.Ctx) error {
+	key, err := s.Repo.FindByID(c.Params("id"))
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "api key not found"})
+	}
+	return c.JSON(fiber.Map{"status": "success", "data": key})
+}
+
+// POST /api/v1/api-keys
+func (s *APIKeyService) Create(c *fiber.Ctx) error {
+	claims := c.Locals("claims").(*utils.JWTClaims)
+
+	var body struct {
+		Name       string     `json:"name"`
+		Scopes     []string   `json:"scopes"`
+		AllowedIPs []string   `json:"allowed_ips"`
+		ExpiresAt  *time.Time `json:"expires_at"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid input"})
+	}
+	body.Name = strings.TrimSpace(body.Name)
+	if body.Name == "" || len(body.Scopes) == 0 {
+		return c.Status(400).JSON(fiber.Map{"error": "name and scopes are required"})
+	}
+	if body.ExpiresAt != nil && !body.ExpiresAt.After(time.Now()) {
+		return c.Status(400).JSON(fiber.Map{"error": "expires_at must be in the future"})
+	}
+
+	if rejected, err := s.validateScopes(c, claims, body.Scopes); rejected {
+		return err
+	}
+	for _, ip := range body.AllowedIPs {
+		if !utils.ValidIPEntry(ip) {
+			return c.Status(400).JSON(fiber.Map{"error": "invalid ip or cidr: " + ip})
+		}
+	}
+
+	plain, prefix, hash, err := utils.GenerateAPIKey()
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed generate api key"})
+	}
+
+	creator := claims.ID
+	key := &models.APIKey{
+		ID:         uuid.New().String(),
+		Name:       body.Name,
+		Prefix:     prefix,
+		KeyHash:    hash,
+		Scopes:     body.Scopes,
+		AllowedIPs: body.AllowedIPs,
+		CreatedBy:  &creator,
+		CreatedAt:  time.Now(),
+		ExpiresAt:  body.ExpiresAt,
+	}
+	if key.AllowedIPs == nil {
+		key.AllowedIPs = []string{}
+	}
+	if err := s.Repo.Create(key); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed create api key"})
+	}
+
+	log.Printf("[AUDIT] api key %s (%s) created by %s with scopes %v", key.ID, key.Name, claims.ID, key.Scopes)
+
+	return c.Status(201).JSON(fiber.Map{
+		"status":  "success",
+		"api_key": plain,
+		"message": "simpan key ini sekarang, key tidak akan ditampilkan lagi",
+		"data":    key,
+	})
+}
+
+// DELETE /api/v1/api-keys/:id
+func (s *APIKeyService) Revoke(c *fiber.Ctx) error {
+	revoked, err := s.Repo.Revoke(c.Params("id"))
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed revoke api key"})
+	}
+	if !revoked {
+		return c.Status(404).JSON(fiber.Map{"error": "api key not found or already revoked"})
+	}
+	return c.JSON(fiber.Map{"status": "success", "message": "api key revoked"})
+}
+
+// validateScopes: scope harus ada di tabel permissions, dimiliki pembuat,
+// dan bukan permission administrasi. true = request ditolak (response sudah ditulis).
+func (s *APIKeyService) validateScopes(c *fiber.Ctx, claims *utils.JWTClaims, scopes []string) (bool, error) {
+	perms, err := s.PermissionRepo.FindAll()
+	if err != nil {
+		return true, c.Status(500).JSON(fiber.Map{"error": "failed load permissions"})
+	}
+	known := map[string]bool{}
+	for _, p := range perms {
+		known[p.Name] = true
+	}
+
+	for _, scope := range scopes {
+		if !known[scope] {
+			return true, c.Status(400).JSON(fiber.Map{"error": "unknown scope: " + scope})
+		}
+		for _, blocked := range models.NonDelegablePermissions {
+			if scope == blocked {
+				return true, c.Status(400).JSON(fiber.Map{"error": "scope cannot be granted to api keys: " + scope})
+			}
+		}
+		if !claims.HasPermission(scope) {
+			return true, c.Status(403).JSON(fiber.Map{"error": "you do not have scope: " + scope})
+		}
+	}
+	return false, nil
+}
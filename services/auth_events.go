@@ -0,0 +1,141 @@
+package services
+
+import (
+	"log"
+	"strings"
+	"time"
+
+	"achievements-uas/app/models"
+	"achievements-uas/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+//
+// ======================= AUTH EVENT LOG =======================
+//
+
+// authEvent adalah data opsional untuk satu event; IP & user agent diambil dari request
+type authEvent struct {
+	Type       string
+	Success    bool
+	UserID     string
+	Identifier string
+	SessionID  string
+	Detail     string
+}
+
+// recordEvent menulis ke log keamanan, kegagalan menulis tidak menggagalkan request
+func (s *AuthService) recordEvent(c *fiber.Ctx, ev authEvent) {
+	if s.EventRepo == nil {
+		return
+	}
+
+	e := &models.AuthEvent{
+		ID:         uuid.New().String(),
+		EventType:  ev.Type,
+		Success:    ev.Success,
+		Identifier: ev.Identifier,
+		IPAddress:  c.IP(),
+		UserAgent:  c.Get(fiber.HeaderUserAgent),
+		Detail:     ev.Detail,
+	}
+	if ev.UserID != "" {
+		e.UserID = &ev.UserID
+	}
+	if ev.SessionID != "" {
+		e.SessionID = &ev.SessionID
+	}
+
+	if err := s.EventRepo.Record(e); err != nil {
+		log.Printf("[WARN] record auth event %s: %v", ev.Type, err)
+	}
+}
+
+// recordClaimsEvent untuk event dari request yang sudah terautentikasi
+func (s *AuthService) recordClaimsEvent(c *fiber.Ctx, eventType string, claims *utils.JWTClaims, detail string) {
+	s.recordEvent(c, authEvent{
+		Type:       eventType,
+		Success:    true,
+		UserID:     claims.ID,
+		Identifier: claims.Username,
+		SessionID:  claims.SessionID,
+		Detail:     detail,
+	})
+}
+
+// GET /api/v1/audit/auth-events?user_id=&type=login,logout&success=false&from=&to=&limit=&offset=
+func (s *AuthService) AuthEvents(c *fiber.Ctx) error {
+	f := models.AuthEventFilter{
+		UserID: c.Query("user_id"),
+		Limit:  c.QueryInt("limit", 100),
+		Offset: c.QueryInt("offset", 0),
+	}
+	if f.Limit <= 0 || f.Limit > 500 {
+		f.Limit = 100
+	}
+	if f.Offset < 0 {
+		f.Offset = 0
+	}
+
+	if raw := c.Query("type"); raw != "" {
+		for _, t := range strings.Split(raw, ",") {
+			t = strings.TrimSpace(t)
+			if !validAuthEventType(t) {
+				return c.Status(400).JSON(fiber.Map{
+					"error":       "invalid event type: " + t,
+					"valid_types": models.AuthEventTypes,
+				})
+			}
+			f.EventTypes = append(f.EventTypes, t)
+		}
+	}
+
+	switch c.Query("success") {
+	case "":
+	case "true":
+		v := true
+		f.Success = &v
+	case "false":
+		v := false
+		f.Success = &v
+	default:
+		return c.Status(400).JSON(fiber.Map{"error": "success must be true or false"})
+	}
+
+	for key, dst := range map[string]**time.Time{"from": &f.From, "to": &f.To} {
+		raw := c.Query(key)
+		if raw == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": key + " must be an RFC3339 timestamp"})
+		}
+		*dst = &t
+	}
+	if f.From != nil && f.To != nil && !f.From.Before(*f.To) {
+		return c.Status(400).JSON(fiber.Map{"error": "from must be before to"})
+	}
+
+	events, total, err := s.EventRepo.List(f)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed get auth events"})
+	}
+
+	return c.JSON(fiber.Map{
+		"status": "success",
+		"data":   events,
+		"meta":   fiber.Map{"total": total, "limit": f.Limit, "offset": f.Offset},
+	})
+}
+
+func validAuthEventType(t string) bool {
+	for _, v := range models.AuthEventTypes {
+		if v == t {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,350 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"achievements-uas/app/models"
+	"achievements-uas/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// =====================================================
+// TOTP TWO-FACTOR AUTHENTICATION
+// =====================================================
+// Login dua langkah: /auth/login mengembalikan mfa_token jika user sudah
+// mengaktifkan MFA atau role-nya ada di MFA_ENFORCED_ROLES. Token asli baru
+// diterbitkan oleh /auth/login/mfa setelah kode TOTP / recovery code valid.
+
+const mfaChallengeTTL = 5 * time.Minute
+
+func mfaIssuer() string {
+	if v := os.Getenv("MFA_ISSUER"); v != "" {
+		return v
+	}
+	return "Achievements UAS"
+}
+
+func (s *AuthService) mfaEnforced(roleName string) bool {
+	roles := os.Getenv("MFA_ENFORCED_ROLES")
+	if roles == "" {
+		roles = "Admin,Dosen Wali"
+	}
+	for _, r := range strings.Split(roles, ",") {
+		if strings.TrimSpace(r) == roleName {
+			return true
+		}
+	}
+	return false
+}
+
+// mfaStatus mengembalikan data MFA user (nil jika belum pernah setup)
+func (s *AuthService) mfaStatus(userID string) (*models.UserMFA, error) {
+	m, err := s.MFARepo.Get(userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return m, err
+}
+
+// mfaChallenge dipanggil Login setelah password benar. required=true berarti
+// response (challenge atau error) sudah ditulis dan login berhenti di sini.
+func (s *AuthService) mfaChallenge(c *fiber.Ctx, user *models.User) (error, bool) {
+	m, err := s.mfaStatus(user.ID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed load mfa status"}), true
+	}
+	enrolled := m != nil && m.Enabled
+
+	if !enrolled {
+		roleName, err := s.RoleRepo.GetNameByID(user.RoleID)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "failed load role"}), true
+		}
+		if !s.mfaEnforced(roleName) {
+			return nil, false
+		}
+	}
+
+	token, err := utils.GenerateMFAToken(user, mfaChallengeTTL)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed generate mfa token"}), true
+	}
+
+	return c.JSON(fiber.Map{
+		"status":              "mfa_required",
+		"mfa_token":           token,
+		"enrollment_required": !enrolled,
+		"expires_in":          int(mfaChallengeTTL.Seconds()),
+	}), true
+}
+
+// userFromMFAToken memvalidasi challenge token (belum dipakai) dan memuat user-nya
+func (s *AuthService) userFromMFAToken(mfaToken string) (*models.User, *utils.JWTClaims, error) {
+	claims, err := utils.ParseMFAToken(mfaToken)
+	if err != nil {
+		return nil, nil, err
+	}
+	if used, err := utils.IsTokenRevoked(claims.TokenID()); err != nil || used {
+		return nil, nil, utils.ErrTokenInvalid
+	}
+
+	user, err := s.AuthRepo.GetProfile(claims.ID)
+	if err != nil || !user.IsActive {
+		return nil, nil, utils.ErrTokenInvalid
+	}
+	return user, claims, nil
+}
+
+// mfaSubject: user dari access token (sudah login) atau dari mfa_token
+// (login pertama kali untuk role yang wajib MFA)
+func (s *AuthService) mfaSubject(c *fiber.Ctx, mfaToken string) (*models.User, *utils.JWTClaims, error) {
+	if claims, ok := c.Locals("claims").(*utils.JWTClaims); ok {
+		user, err := s.AuthRepo.GetProfile(claims.ID)
+		return user, nil, err
+	}
+	return s.userFromMFAToken(mfaToken)
+}
+
+// issueRecoveryCodes membuat ulang recovery code dan mengembalikan versi plain
+func (s *AuthService) issueRecoveryCodes(userID string) ([]string, error) {
+	codes, err := utils.GenerateRecoveryCodes(10)
+	if err != nil {
+		return nil, err
+	}
+	hashes := make([]string, len(codes))
+	for i, code := range codes {
+		hashes[i] = utils.HashOneTimeToken(code)
+	}
+	if err := s.MFARepo.ReplaceRecoveryCodes(userID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// verifySecondFactor mengecek kode TOTP atau recovery code
+func (s *AuthService) verifySecondFactor(m *models.UserMFA, code, recoveryCode string) bool {
+	if recoveryCode != "" {
+		ok, err := s.MFARepo.UseRecoveryCode(m.UserID, utils.HashOneTimeToken(utils.NormalizeRecoveryCode(recoveryCode)))
+		if err != nil {
+			log.Printf("[WARN] use recovery code %s: %v", m.UserID, err)
+		}
+		return ok
+	}
+
+	step, ok := utils.ValidateTOTP(m.Secret, code, m.LastUsedStep)
+	if !ok {
+		return false
+	}
+	fresh, err := s.MFARepo.MarkStepUsed(m.UserID, step)
+	return err == nil && fresh
+}
+
+//
+// ======================= LOGIN STEP 2 =======================
+//
+
+// POST /api/v1/auth/login/mfa
+func (s *AuthService) LoginMFA(c *fiber.Ctx) error {
+	var body struct {
+		MFAToken     string `json:"mfa_token"`
+		Code         string `json:"code"`
+		RecoveryCode string `json:"recovery_code"`
+	}
+	if err := c.BodyParser(&body); err != nil || body.MFAToken == "" {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "mfa_token is required"})
+	}
+
+	user, claims, err := s.userFromMFAToken(body.MFAToken)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"error": "invalid or expired mfa token"})
+	}
+
+	m, err := s.mfaStatus(user.ID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed load mfa status"})
+	}
+	if m == nil || !m.Enabled {
+		return c.Status(409).JSON(fiber.Map{"error": "mfa enrollment required", "enrollment_required": true})
+	}
+
+	if blocked, err := s.checkAccountLock(c, user.Username, user); blocked {
+		return err
+	}
+	if !s.verifySecondFactor(m, body.Code, body.RecoveryCode) {
+		s.registerFailure(c, user.Username, user)
+		return c.Status(401).JSON(fiber.Map{"error": "invalid mfa code"})
+	}
+
+	// challenge token hanya boleh dipakai sekali
+	utils.RevokeToken(claims.TokenID(), claims.ExpiresAt.Time)
+	s.registerSuccess(c, user.Username, user)
+
+	return s.completeLogin(c, user, nil)
+}
+
+//
+// ======================= ENROLLMENT =======================
+//
+
+// POST /api/v1/auth/mfa/setup (login biasa)
+// POST /api/v1/auth/login/mfa/setup (dengan mfa_token, role wajib MFA)
+func (s *AuthService) SetupMFA(c *fiber.Ctx) error {
+	var body struct {
+		MFAToken string `json:"mfa_token"`
+	}
+	c.BodyParser(&body)
+
+	user, _, err := s.mfaSubject(c, body.MFAToken)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"error": "invalid or expired token"})
+	}
+
+	m, err := s.mfaStatus(user.ID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed load mfa status"})
+	}
+	if m != nil && m.Enabled {
+		return c.Status(409).JSON(fiber.Map{"error": "mfa already enabled"})
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed generate secret"})
+	}
+	if err := s.MFARepo.SaveSecret(user.ID, secret); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed save secret"})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":           "success",
+		"secret":           secret,
+		"provisioning_uri": utils.TOTPProvisioningURI(mfaIssuer(), user.Username, secret),
+	})
+}
+
+// POST /api/v1/auth/mfa/confirm
+// POST /api/v1/auth/login/mfa/confirm (juga menerbitkan token login)
+func (s *AuthService) ConfirmMFA(c *fiber.Ctx) error {
+	var body struct {
+		MFAToken string `json:"mfa_token"`
+		Code     string `json:"code"`
+	}
+	if err := c.BodyParser(&body); err != nil || body.Code == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "code is required"})
+	}
+
+	user, challenge, err := s.mfaSubject(c, body.MFAToken)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"error": "invalid or expired token"})
+	}
+
+	m, err := s.mfaStatus(user.ID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed load mfa status"})
+	}
+	if m == nil {
+		return c.Status(400).JSON(fiber.Map{"error": "mfa setup has not been started"})
+	}
+	if m.Enabled {
+		return c.Status(409).JSON(fiber.Map{"error": "mfa already enabled"})
+	}
+
+	step, ok := utils.ValidateTOTP(m.Secret, body.Code, m.LastUsedStep)
+	if !ok {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid mfa code"})
+	}
+	if err := s.MFARepo.Enable(user.ID, step); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed enable mfa"})
+	}
+
+	codes, err := s.issueRecoveryCodes(user.ID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed generate recovery codes"})
+	}
+
+	// enrollment saat login: langsung selesaikan login
+	if challenge != nil {
+		utils.RevokeToken(challenge.TokenID(), challenge.ExpiresAt.Time)
+		return s.completeLogin(c, user, fiber.Map{"recovery_codes": codes})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":         "success",
+		"message":        "mfa enabled",
+		"recovery_codes": codes,
+	})
+}
+
+// POST /api/v1/auth/mfa/recovery-codes
+func (s *AuthService) RegenerateRecoveryCodes(c *fiber.Ctx) error {
+	claims := c.Locals("claims").(*utils.JWTClaims)
+
+	var body struct {
+		Code string `json:"code"`
+	}
+	c.BodyParser(&body)
+
+	m, err := s.mfaStatus(claims.ID)
+	if err != nil || m == nil || !m.Enabled {
+		return c.Status(400).JSON(fiber.Map{"error": "mfa is not enabled"})
+	}
+	if !s.verifySecondFactor(m, body.Code, "") {
+		return c.Status(401).JSON(fiber.Map{"error": "invalid mfa code"})
+	}
+
+	codes, err := s.issueRecoveryCodes(claims.ID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed generate recovery codes"})
+	}
+	return c.JSON(fiber.Map{"status": "success", "recovery_codes": codes})
+}
+
+// POST /api/v1/auth/mfa/disable
+func (s *AuthService) DisableMFA(c *fiber.Ctx) error {
+	claims := c.Locals("claims").(*utils.JWTClaims)
+
+	var body struct {
+		Password string `json:"password"`
+		Code     string `json:"code"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid input"})
+	}
+
+	if s.mfaEnforced(claims.Role) {
+		return c.Status(403).JSON(fiber.Map{"error": "mfa is mandatory for your role"})
+	}
+
+	user, err := s.AuthRepo.GetProfile(claims.ID)
+	if err != nil || s.authenticate(user, body.Password) != nil {
+		return c.Status(401).JSON(fiber.Map{"error": "password is incorrect"})
+	}
+
+	m, err := s.mfaStatus(claims.ID)
+	if err != nil || m == nil || !m.Enabled {
+		return c.Status(400).JSON(fiber.Map{"error": "mfa is not enabled"})
+	}
+	if !s.verifySecondFactor(m, body.Code, "") {
+		return c.Status(401).JSON(fiber.Map{"error": "invalid mfa code"})
+	}
+
+	if err := s.MFARepo.Disable(claims.ID); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed disable mfa"})
+	}
+	return c.JSON(fiber.Map{"status": "success", "message": "mfa disabled"})
+}
+
+// DELETE /api/v1/users/:id/mfa (admin reset, misal HP user hilang)
+func (s *AuthService) ResetUserMFA(c *fiber.Ctx) error {
+	if err := s.MFARepo.Disable(c.Params("id")); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed reset mfa"})
+	}
+	return c.JSON(fiber.Map{"status": "success", "message": "mfa reset, user must enroll again"})
+}
@@ -0,0 +1,270 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"achievements-uas/app/models"
+	"achievements-uas/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// =====================================================
+// CAMPUS SSO (OPENID CONNECT)
+// =====================================================
+// GET /auth/oidc/login    -> redirect ke IdP (state + nonce + PKCE di cookie)
+// GET /auth/oidc/callback -> tukar code, cocokkan user, terbitkan token biasa
+//
+// Pencocokan user:
+//  1. subject yang sudah ter-link di user_identities
+//  2. email terverifikasi yang sama dengan users.email (OIDC_LINK_BY_EMAIL, default true)
+//  3. provisioning user baru jika OIDC_AUTO_PROVISION=true, domain email ada di
+//     OIDC_ALLOWED_DOMAINS (kosong = semua) dan role OIDC_DEFAULT_ROLE
+
+const (
+	oidcStateCookie = "oidc_state"
+	oidcStateTTL    = 10 * time.Minute
+)
+
+var errNoLinkedAccount = errors.New("no account linked to this identity")
+
+func oidcProviderName() string {
+	if v := os.Getenv("OIDC_PROVIDER_NAME"); v != "" {
+		return v
+	}
+	return "campus"
+}
+
+// GET /api/v1/auth/oidc/login
+func (s *AuthService) OIDCLogin(c *fiber.Ctx) error {
+	if s.OIDC == nil || !s.OIDC.Config.Enabled() {
+		return c.Status(404).JSON(fiber.Map{"error": "sso login is not enabled"})
+	}
+
+	state, signed, err := utils.NewOIDCState(oidcStateTTL)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed create sso state"})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	redirect, err := s.OIDC.AuthCodeURL(ctx, state.State, state.Nonce, state.Verifier)
+	if err != nil {
+		log.Printf("[WARN] oidc discovery: %v", err)
+		return c.Status(502).JSON(fiber.Map{"error": "identity provider unavailable"})
+	}
+
+	c.Cookie(&fiber.Cookie{
+		Name:     oidcStateCookie,
+		Value:    signed,
+		Path:     "/",
+		Expires:  time.Now().Add(oidcStateTTL),
+		HTTPOnly: true,
+		Secure:   c.Protocol() == "https",
+		SameSite: fiber.CookieSameSiteLaxMode,
+	})
+
+	return c.Redirect(redirect, fiber.StatusFound)
+}
+
+// GET /api/v1/auth/oidc/callback
+func (s *AuthService) OIDCCallback(c *fiber.Ctx) error {
+	if s.OIDC == nil || !s.OIDC.Config.Enabled() {
+		return c.Status(404).JSON(fiber.Map{"error": "sso login is not enabled"})
+	}
+
+	if e := c.Query("error"); e != "" {
+		return c.Status(401).JSON(fiber.Map{"error": "sso login failed", "detail": e})
+	}
+
+	state, err := utils.ParseOIDCState(c.Cookies(oidcStateCookie))
+	// cookie state hanya sekali pakai
+	c.ClearCookie(oidcStateCookie)
+	if err != nil || c.Query("state") == "" || c.Query("state") != state.State {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid or expired sso state"})
+	}
+	if c.Query("code") == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "authorization code is missing"})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	identity, err := s.OIDC.Exchange(ctx, c.Query("code"), state.Verifier, state.Nonce)
+	if err != nil {
+		log.Printf("[WARN] oidc exchange: %v", err)
+		return c.Status(401).JSON(fiber.Map{"error": "sso login failed"})
+	}
+
+	user, err := s.resolveOIDCUser(identity)
+	if errors.Is(err, errNoLinkedAccount) {
+		s.recordAttempt(c, "sso:"+identity.Email, nil, false, "sso account not linked")
+		return c.Status(403).JSON(fiber.Map{"error": "no account is linked to this sso identity"})
+	}
+	if err != nil {
+		log.Printf("[WARN] oidc resolve user: %v", err)
+		return c.Status(500).JSON(fiber.Map{"error": "failed resolve sso user"})
+	}
+
+	if !user.IsActive {
+		s.recordAttempt(c, "sso:"+identity.Email, &user.ID, false, "inactive user")
+		return c.Status(403).JSON(fiber.Map{"error": "user inactive"})
+	}
+
+	s.registerSuccess(c, "sso:"+identity.Email, user)
+
+	// MFA tetap berlaku untuk role yang diwajibkan
+	if resp, required := s.mfaChallenge(c, user); required {
+		return resp
+	}
+
+	return s.completeLogin(c, user, fiber.Map{"login_method": "sso"})
+}
+
+// resolveOIDCUser mencari / me-link / membuat user untuk identitas IdP
+func (s *AuthService) resolveOIDCUser(id *utils.OIDCIdentity) (*models.User, error) {
+	provider := oidcProviderName()
+	email := strings.ToLower(strings.TrimSpace(id.Email))
+
+	userID, err := s.IdentityRepo.FindUserID(provider, id.Subject)
+	if err == nil {
+		if err := s.IdentityRepo.Touch(provider, id.Subject, email); err != nil {
+			log.Printf("[WARN] touch identity: %v", err)
+		}
+		return s.AuthRepo.GetProfile(userID)
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	// email hanya dipercaya jika IdP menyatakan sudah terverifikasi
+	var user *models.User
+	if email != "" && id.EmailVerified && os.Getenv("OIDC_LINK_BY_EMAIL") != "false" {
+		user, err = s.AuthRepo.FindByEmail(email)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+	}
+
+	if user == nil {
+		if user, err = s.provisionOIDCUser(id, email); err != nil {
+			return nil, err
+		}
+	}
+
+	link := &models.UserIdentity{
+		ID:       uuid.New().String(),
+		UserID:   user.ID,
+		Provider: provider,
+		Subject:  id.Subject,
+		Email:    email,
+	}
+	if err := s.IdentityRepo.Link(link); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (s *AuthService) provisionOIDCUser(id *utils.OIDCIdentity, email string) (*models.User, error) {
+	if os.Getenv("OIDC_AUTO_PROVISION") != "true" || email == "" || !id.EmailVerified {
+		return nil, errNoLinkedAccount
+	}
+	if !emailDomainAllowed(email, os.Getenv("OIDC_ALLOWED_DOMAINS")) {
+		return nil, errNoLinkedAccount
+	}
+
+	roleName := os.Getenv("OIDC_DEFAULT_ROLE")
+	if roleName == "" {
+		roleName = "Mahasiswa"
+	}
+	role, err := s.RoleRepo.FindByName(roleName)
+	if err != nil {
+		return nil, fmt.Errorf("default sso role %q: %w", roleName, err)
+	}
+
+	username, err := s.availableUsername(id.PreferredUsername, email)
+	if err != nil {
+		return nil, err
+	}
+
+	// password acak yang tidak pernah diberikan ke siapa pun; user login via SSO
+	// atau memakai alur forgot-password jika butuh password lokal
+	random, _, err := utils.GenerateOneTimeToken()
+	if err != nil {
+		return nil, err
+	}
+	hash, err := utils.HashPassword(random)
+	if err != nil {
+		return nil, err
+	}
+
+	fullName := id.Name
+	if fullName == "" {
+		fullName = username
+	}
+
+	user := &models.User{
+		ID:           uuid.New().String(),
+		Username:     username,
+		Email:        email,
+		PasswordHash: hash,
+		FullName:     fullName,
+		RoleID:       role.ID,
+		IsActive:     true,
+	}
+	if err := s.AuthRepo.CreateSSOUser(user); err != nil {
+		return nil, err
+	}
+
+	log.Printf("[AUDIT] sso provisioned user %s (%s) with role %s", user.ID, email, role.Name)
+	return user, nil
+}
+
+var usernameSanitizer = regexp.MustCompile(`[^a-z0-9._-]+`)
+
+func (s *AuthService) availableUsername(preferred, email string) (string, error) {
+	base := strings.ToLower(preferred)
+	if base == "" {
+		base = strings.SplitN(email, "@", 2)[0]
+	}
+	base = usernameSanitizer.ReplaceAllString(strings.ToLower(base), "")
+	if base == "" {
+		base = "user"
+	}
+
+	candidate := base
+	for i := 2; i < 100; i++ {
+		exists, err := s.AuthRepo.UsernameExists(candidate)
+		if err != nil {
+			return "", err
+		}
+		if !exists {
+			return candidate, nil
+		}
+		candidate = fmt.Sprintf("%s%d", base, i)
+	}
+	return "", errors.New("no available username for " + base)
+}
+
+func emailDomainAllowed(email, allowed string) bool {
+	if strings.TrimSpace(allowed) == "" {
+		return true
+	}
+	domain := email[strings.LastIndex(email, "@")+1:]
+	for _, d := range strings.Split(allowed, ",") {
+		if strings.EqualFold(strings.TrimSpace(d), domain) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,182 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"achievements-uas/app/models"
+	"achievements-uas/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+func passwordResetTTL() time.Duration {
+	d, err := time.ParseDuration(os.Getenv("PASSWORD_RESET_TTL"))
+	if err != nil {
+		return 30 * time.Minute
+	}
+	return d
+}
+
+//
+// ======================= CHANGE PASSWORD (SELF) =======================
+//
+
+// POST /api/v1/auth/password
+func (s *AuthService) ChangePassword(c *fiber.Ctx) error {
+	claims := c.Locals("claims").(*utils.JWTClaims)
+
+	var body struct {
+		CurrentPassword string `json:"current_password"`
+		NewPassword     string `json:"new_password"`
+	}
+	if err := c.BodyParser(&body); err != nil || body.CurrentPassword == "" || body.NewPassword == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "current_password and new_password are required"})
+	}
+
+	user, err := s.AuthRepo.GetProfile(claims.ID)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "user not found"})
+	}
+	if s.authSourceFor(user) != AuthSourceLocal {
+		return c.Status(409).JSON(fiber.Map{"error": "password is managed by the directory service"})
+	}
+
+	currentHash, err := s.AuthRepo.GetPasswordHash(claims.ID)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "user not found"})
+	}
+	if !utils.VerifyPassword(currentHash, body.CurrentPassword) {
+		s.recordEvent(c, authEvent{
+			Type:       models.AuthEventPasswordChange,
+			UserID:     claims.ID,
+			Identifier: claims.Username,
+			SessionID:  claims.SessionID,
+			Detail:     "current password is incorrect",
+		})
+		return c.Status(401).JSON(fiber.Map{"error": "current password is incorrect"})
+	}
+
+	if err := s.Passwords.Validate(claims.ID, body.NewPassword); err != nil {
+		return policyErrorResponse(c, err)
+	}
+
+	hash, err := utils.HashPassword(body.NewPassword)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed hash password"})
+	}
+	if err := s.AuthRepo.UpdatePassword(claims.ID, hash); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed update password"})
+	}
+	s.Passwords.Record(claims.ID, hash)
+
+	// session lain (perangkat lain) wajib login ulang, session ini tetap aktif
+	n, err := s.revokeUserSessions(claims.ID, claims.SessionID, "password changed")
+	if err != nil {
+		log.Printf("[WARN] revoke sessions after password change: %v", err)
+	}
+	s.recordClaimsEvent(c, models.AuthEventPasswordChange, claims, "")
+
+	return c.JSON(fiber.Map{
+		"status":           "success",
+		"message":          "password updated",
+		"revoked_sessions": n,
+	})
+}
+
+//
+// ======================= FORGOT PASSWORD =======================
+//
+
+// POST /api/v1/auth/forgot-password
+// Response selalu sama agar tidak bisa dipakai untuk menebak akun yang terdaftar.
+func (s *AuthService) ForgotPassword(c *fiber.Ctx) error {
+	var body struct {
+		Email string `json:"email"`
+	}
+	if err := c.BodyParser(&body); err != nil || body.Email == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "email is required"})
+	}
+
+	response := fiber.Map{
+		"status":  "success",
+		"message": "if the account exists, a reset link has been sent",
+	}
+
+	// akun direktori (LDAP) tidak punya password lokal yang bisa di-reset
+	user, err := s.AuthRepo.FindByEmail(body.Email)
+	if err != nil || !user.IsActive || s.authSourceFor(user) != AuthSourceLocal {
+		return c.JSON(response)
+	}
+
+	plain, hash, err := utils.GenerateOneTimeToken()
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed generate token"})
+	}
+
+	ttl := passwordResetTTL()
+	if err := s.ResetRepo.Create(uuid.New().String(), user.ID, hash, time.Now().Add(ttl)); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed create reset token"})
+	}
+
+	link := fmt.Sprintf("%s/reset-password?token=%s", os.Getenv("APP_BASE_URL"), plain)
+	mailBody := fmt.Sprintf(
+		"Halo %s,\n\nGunakan link berikut untuk mengatur ulang password anda (berlaku %s):\n%s\n\nAbaikan email ini jika anda tidak meminta reset password.",
+		user.FullName, ttl, link,
+	)
+	if err := s.Mailer.Send(user.Email, "Reset Password", mailBody); err != nil {
+		log.Printf("[WARN] send reset mail to %s: %v", user.Email, err)
+	}
+
+	return c.JSON(response)
+}
+
+//
+// ======================= RESET PASSWORD =======================
+//
+
+// POST /api/v1/auth/reset-password
+func (s *AuthService) ResetPassword(c *fiber.Ctx) error {
+	var body struct {
+		Token       string `json:"token"`
+		NewPassword string `json:"new_password"`
+	}
+	if err := c.BodyParser(&body); err != nil || body.Token == "" || body.NewPassword == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "token and new_password are required"})
+	}
+
+	tokenHash := utils.HashOneTimeToken(body.Token)
+
+	// validasi password dulu agar token tidak hangus karena password ditolak policy
+	userID, err := s.ResetRepo.FindValid(tokenHash)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid or expired token"})
+	}
+	if err := s.Passwords.Validate(userID, body.NewPassword); err != nil {
+		return policyErrorResponse(c, err)
+	}
+
+	if userID, err = s.ResetRepo.Consume(tokenHash); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid or expired token"})
+	}
+
+	hash, err := utils.HashPassword(body.NewPassword)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed hash password"})
+	}
+	if err := s.AuthRepo.UpdatePassword(userID, hash); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed update password"})
+	}
+	s.Passwords.Record(userID, hash)
+
+	// semua session lama di-revoke, user harus login dengan password baru
+	if _, err := s.revokeUserSessions(userID, "", "password reset"); err != nil {
+		log.Printf("[WARN] revoke sessions after password reset: %v", err)
+	}
+	s.recordEvent(c, authEvent{Type: models.AuthEventPasswordReset, Success: true, UserID: userID, Detail: "forgot-password link"})
+
+	return c.JSON(fiber.Map{"status": "success", "message": "password has been reset"})
+}
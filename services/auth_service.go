@@ -1,9 +1,15 @@
 package services
 
 import (
+	"errors"
+	"fmt"
+	"log"
 	"net/http"
+	"time"
 
+	"achievements-uas/app/models"
 	"achievements-uas/app/repository"
+	"achievements-uas/middleware"
 	"achievements-uas/utils"
 	"github.com/gofiber/fiber/v2"
 )
@@ -12,17 +18,52 @@ type AuthService struct {
 	AuthRepo     *repository.AuthRepository
 	RoleRepo     *repository.RoleRepository
 	RolePermRepo *repository.RolePermissionRepository
+	SessionRepo  *repository.SessionRepository
+	ResetRepo    *repository.PasswordResetRepository
+	Mailer       utils.Mailer
+	Passwords    *PasswordService
+	AttemptRepo  *repository.LoginAttemptRepository
+	MFARepo      *repository.MFARepository
+	IdentityRepo *repository.IdentityRepository
+	EventRepo    *repository.AuthEventRepository
+	OIDC         *utils.OIDCProvider
+	// backend password per auth source (local, ldap)
+	Authenticators map[string]Authenticator
+
+	guardConfig  loginGuardConfig
+	cookieConfig middleware.CookieAuthConfig
 }
 
 func NewAuthService(
 	authRepo *repository.AuthRepository,
 	roleRepo *repository.RoleRepository,
 	rolePermRepo *repository.RolePermissionRepository,
+	sessionRepo *repository.SessionRepository,
+	resetRepo *repository.PasswordResetRepository,
+	mailer utils.Mailer,
+	passwords *PasswordService,
+	attemptRepo *repository.LoginAttemptRepository,
+	mfaRepo *repository.MFARepository,
+	identityRepo *repository.IdentityRepository,
+	eventRepo *repository.AuthEventRepository,
+	oidc *utils.OIDCProvider,
 ) *AuthService {
 	return &AuthService{
 		AuthRepo:     authRepo,
 		RoleRepo:     roleRepo,
 		RolePermRepo: rolePermRepo,
+		SessionRepo:  sessionRepo,
+		ResetRepo:    resetRepo,
+		Mailer:       mailer,
+		Passwords:    passwords,
+		AttemptRepo:  attemptRepo,
+		MFARepo:      mfaRepo,
+		IdentityRepo: identityRepo,
+		EventRepo:    eventRepo,
+		OIDC:         oidc,
+		Authenticators: defaultAuthenticators(authRepo),
+		guardConfig:  loadLoginGuardConfig(),
+		cookieConfig: middleware.CookieAuthConfigFromEnv(),
 	}
 }
 
@@ -40,113 +81,239 @@ func (s *AuthService) Login(c *fiber.Ctx) error {
 			JSON(fiber.Map{"error": "invalid input"})
 	}
 
+	// ===== throttling per IP =====
+	if blocked, err := s.checkIPThrottle(c, body.Username); blocked {
+		return err
+	}
+
 	user, err := s.AuthRepo.GetByUsernameOrEmail(body.Username)
+	if errors.Is(err, repository.ErrAmbiguousIdentifier) {
+		s.recordAttempt(c, body.Username, nil, false, "ambiguous identifier")
+		return c.Status(http.StatusConflict).
+			JSON(fiber.Map{"error": "identifier matches more than one account, login with username or email"})
+	}
 	if err != nil {
+		s.recordAttempt(c, body.Username, nil, false, "unknown user")
 		return c.Status(http.StatusUnauthorized).
 			JSON(fiber.Map{"error": "invalid credentials"})
 	}
 
+	// ===== lockout & backoff per akun =====
+	if blocked, err := s.checkAccountLock(c, body.Username, user); blocked {
+		return err
+	}
+
+	// ===== verifikasi password lewat backend user (bcrypt / LDAP) =====
+	if err := s.authenticate(user, body.Password); err != nil {
+		if errors.Is(err, ErrInvalidCredentials) {
+			s.registerFailure(c, body.Username, user)
+			return c.Status(http.StatusUnauthorized).
+				JSON(fiber.Map{"error": "invalid credentials"})
+		}
+		log.Printf("[WARN] authenticate %s: %v", user.ID, err)
+		return c.Status(http.StatusServiceUnavailable).
+			JSON(fiber.Map{"error": "authentication service unavailable"})
+	}
+
 	if !user.IsActive {
+		s.recordAttempt(c, body.Username, &user.ID, false, "user inactive")
 		return c.Status(http.StatusForbidden).
 			JSON(fiber.Map{"error": "user inactive"})
 	}
 
-	if !utils.VerifyPassword(user.PasswordHash, body.Password) {
-		return c.Status(http.StatusUnauthorized).
-			JSON(fiber.Map{"error": "invalid credentials"})
-	}
+	s.registerSuccess(c, body.Username, user)
 
-	// ===== ambil ROLE NAME =====
-	roleName, err := s.RoleRepo.GetNameByID(user.RoleID)
-	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "failed load role"})
+	// ===== MFA: token baru diterbitkan setelah kode TOTP diverifikasi =====
+	if resp, required := s.mfaChallenge(c, user); required {
+		return resp
 	}
 
-	// ===== ambil PERMISSIONS =====
-	perms, err := s.RolePermRepo.GetPermissionsByRole(user.RoleID)
-	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "failed fetch permissions"})
-	}
+	return s.completeLogin(c, user, nil)
+}
 
-	// ===== generate token =====
-	accessToken, err := utils.GenerateAccessToken(user, roleName, perms)
+// completeLogin membuat session baru dan mengirim response login standar
+func (s *AuthService) completeLogin(c *fiber.Ctx, user *models.User, extra fiber.Map) error {
+	tokens, err := s.startSession(c, user)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": "failed generate token"})
 	}
 
-	refreshToken, err := utils.GenerateRefreshToken(user)
-	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "failed generate refresh token"})
+	method := "password"
+	if m, ok := extra["login_method"].(string); ok {
+		method = m
 	}
+	s.recordEvent(c, authEvent{
+		Type:       models.AuthEventLogin,
+		Success:    true,
+		UserID:     user.ID,
+		Identifier: user.Username,
+		SessionID:  tokens.SessionID,
+		Detail:     method,
+	})
 
-	return c.JSON(fiber.Map{
-		"status":        "success",
-		"access_token":  accessToken,
-		"refresh_token": refreshToken,
+	resp := fiber.Map{
+		"status": "success",
 		"user": fiber.Map{
 			"id":          user.ID,
 			"username":    user.Username,
 			"email":       user.Email,
-			"role":        roleName,
-			"permissions": perms,
+			"role":        tokens.RoleName,
+			"permissions": tokens.Permissions,
 		},
-	})
+	}
+	if err := s.writeTokens(c, resp, tokens.AccessToken, tokens.RefreshToken); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed set auth cookies"})
+	}
+	for k, v := range extra {
+		resp[k] = v
+	}
+	return c.JSON(resp)
+}
+
+// writeTokens mengirim token di body (mode header) atau di cookie HttpOnly
+// (mode cookie, body hanya berisi CSRF token)
+func (s *AuthService) writeTokens(c *fiber.Ctx, resp fiber.Map, accessToken, refreshToken string) error {
+	if !s.cookieConfig.Enabled {
+		resp["access_token"] = accessToken
+		resp["refresh_token"] = refreshToken
+		return nil
+	}
+
+	now := time.Now()
+	csrf, err := middleware.SetAuthCookies(c, s.cookieConfig,
+		accessToken, now.Add(utils.AccessTokenTTL()),
+		refreshToken, now.Add(utils.RefreshTokenTTL()))
+	if err != nil {
+		return err
+	}
+	resp["csrf_token"] = csrf
+	return nil
 }
 
 //
 // ======================= REFRESH =======================
 //
+// Setiap refresh merotasi refresh token. Jika refresh token lama dipakai ulang,
+// seluruh session (keluarga token) langsung di-revoke.
 func (s *AuthService) Refresh(c *fiber.Ctx) error {
 	var body struct {
 		RefreshToken string `json:"refresh_token"`
 	}
 
-	if err := c.BodyParser(&body); err != nil {
+	if err := c.BodyParser(&body); err != nil && !s.cookieConfig.Enabled {
 		return c.Status(400).JSON(fiber.Map{"error": "invalid input"})
 	}
 
+	// mode cookie: refresh token dari cookie, wajib double-submit CSRF
+	if body.RefreshToken == "" && s.cookieConfig.Enabled {
+		body.RefreshToken = c.Cookies(middleware.RefreshTokenCookie)
+		if body.RefreshToken != "" && !middleware.CSRFValid(c) {
+			return c.Status(403).JSON(fiber.Map{"error": "missing or invalid CSRF token"})
+		}
+	}
+
 	claims, err := utils.ParseRefreshToken(body.RefreshToken)
-	if err != nil {
+	if err != nil || claims.SessionID == "" {
+		s.recordEvent(c, authEvent{Type: models.AuthEventRefresh, Detail: "invalid refresh token"})
+		return c.Status(401).JSON(fiber.Map{"error": "invalid refresh token"})
+	}
+
+	// event gagal yang tokennya valid dicatat dengan user & session
+	refreshFailed := func(detail string) {
+		s.recordEvent(c, authEvent{
+			Type:       models.AuthEventRefresh,
+			UserID:     claims.ID,
+			Identifier: claims.Username,
+			SessionID:  claims.SessionID,
+			Detail:     detail,
+		})
+	}
+
+	session, err := s.SessionRepo.FindByID(claims.SessionID)
+	if err != nil || session.UserID != claims.ID {
+		refreshFailed("session not found")
 		return c.Status(401).JSON(fiber.Map{"error": "invalid refresh token"})
 	}
+	if session.RevokedAt != nil || time.Now().After(session.ExpiresAt) {
+		refreshFailed("session expired or revoked")
+		return c.Status(401).JSON(fiber.Map{"error": "session expired or revoked"})
+	}
+
+	// ===== reuse detection =====
+	if session.RefreshJTI != claims.TokenID() {
+		s.revokeSession(session, "refresh token reuse detected")
+		refreshFailed("refresh token reuse detected, session revoked")
+		return c.Status(401).JSON(fiber.Map{"error": "refresh token reuse detected, session revoked"})
+	}
 
 	user, err := s.AuthRepo.GetProfile(claims.ID)
 	if err != nil {
+		refreshFailed("user not found")
 		return c.Status(401).JSON(fiber.Map{"error": "user not found"})
 	}
+	if !user.IsActive {
+		refreshFailed("user inactive")
+		return c.Status(http.StatusForbidden).JSON(fiber.Map{"error": "user inactive"})
+	}
 
-	roleName, err := s.RoleRepo.GetNameByID(user.RoleID)
+	refreshToken, refreshClaims, err := utils.GenerateRefreshToken(user, session.ID)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "failed load role"})
+		return c.Status(500).JSON(fiber.Map{"error": "failed generate refresh token"})
 	}
 
-	perms, err := s.RolePermRepo.GetPermissionsByRole(user.RoleID)
+	rotated, err := s.SessionRepo.Rotate(session.ID, claims.TokenID(), refreshClaims.TokenID(), refreshClaims.ExpiresAt.Time)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "failed fetch permissions"})
+		return c.Status(500).JSON(fiber.Map{"error": "failed rotate session"})
+	}
+	if !rotated {
+		// refresh token yang sama dipakai bersamaan / sudah dirotasi request lain
+		s.revokeSession(session, "refresh token reuse detected")
+		refreshFailed("refresh token reuse detected, session revoked")
+		return c.Status(401).JSON(fiber.Map{"error": "refresh token reuse detected, session revoked"})
 	}
 
-	accessToken, err := utils.GenerateAccessToken(user, roleName, perms)
+	accessToken, _, _, err := s.signAccessToken(user, session.ID)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": "failed generate token"})
 	}
 
-	return c.JSON(fiber.Map{
-		"status":       "success",
-		"access_token": accessToken,
+	s.recordEvent(c, authEvent{
+		Type:       models.AuthEventRefresh,
+		Success:    true,
+		UserID:     user.ID,
+		Identifier: user.Username,
+		SessionID:  session.ID,
 	})
+
+	resp := fiber.Map{"status": "success"}
+	if err := s.writeTokens(c, resp, accessToken, refreshToken); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed set auth cookies"})
+	}
+	return c.JSON(resp)
 }
 
 func (s *AuthService) Logout(c *fiber.Ctx) error {
-    // Ambil token dari Locals (yang sudah dibersihkan oleh middleware)
-    tokenString, ok := c.Locals("token").(string)
+    claims, ok := c.Locals("claims").(*utils.JWTClaims)
     if !ok {
         return c.Status(500).JSON(fiber.Map{"error": "Token not found in context"})
     }
 
-    claims := c.Locals("claims").(*utils.JWTClaims)
-    
-    // Gunakan waktu expiry dari token
-    utils.BlacklistToken(tokenString, claims.ExpiresAt.Time)
+    // Revoke berdasarkan jti, berlaku sampai waktu expiry token
+    if err := utils.RevokeToken(claims.TokenID(), claims.ExpiresAt.Time); err != nil {
+        return c.Status(500).JSON(fiber.Map{"error": "failed revoke token"})
+    }
+
+    // Matikan session agar refresh token tidak bisa dipakai lagi
+    if claims.SessionID != "" {
+        if session, err := s.SessionRepo.FindByID(claims.SessionID); err == nil {
+            s.revokeSession(session, "logout")
+        }
+    }
+    s.recordClaimsEvent(c, models.AuthEventLogout, claims, "")
+
+    if s.cookieConfig.Enabled {
+        middleware.ClearAuthCookies(c, s.cookieConfig)
+    }
 
     return c.JSON(fiber.Map{"message": "Logout success"})
 }
@@ -170,11 +337,30 @@ func (s *AuthService) Profile(c *fiber.Ctx) error {
         return c.Status(404).JSON(fiber.Map{"error": "User not found"})
     }
 
+    data := fiber.Map{
+        "user": user,
+        "role": claims.Role,
+    }
+
+    // Penanda jelas bahwa admin sedang "login sebagai" user ini
+    if claims.IsImpersonation() {
+        data["impersonation"] = fiber.Map{
+            "active":         true,
+            "admin_id":       claims.Actor.ID,
+            "admin_username": claims.Actor.Username,
+            "expires_at":     claims.ExpiresAt.Time,
+        }
+    }
+
     return c.Status(200).JSON(fiber.Map{
         "status": "success",
-        "data": fiber.Map{
-            "user": user,
-            "role": claims.Role,
-        },
+        "data":   data,
     })
-}
\ No newline at end of file
+}
+
+// GET /.well-known/jwks.json
+// Public key untuk verifikasi access token oleh service kampus lain
+func (s *AuthService) JWKS(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderCacheControl, fmt.Sprintf("public, max-age=%d", int(utils.JWKSCacheTTL().Seconds())))
+	return c.JSON(utils.JWKS())
+}
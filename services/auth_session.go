@@ -0,0 +1,218 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"achievements-uas/app/models"
+	"achievements-uas/middleware"
+	"achievements-uas/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// tokenPair adalah hasil penerbitan token untuk satu session baru
+type tokenPair struct {
+	AccessToken  string
+	RefreshToken string
+	SessionID    string
+	RoleName     string
+	Permissions  []string
+}
+
+// signAccessToken memuat role + permissions terbaru lalu menerbitkan access token
+func (s *AuthService) signAccessToken(user *models.User, sessionID string) (string, string, []string, error) {
+	roleName, err := s.RoleRepo.GetNameByID(user.RoleID)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	perms, version, err := utils.RolePermissions(user.RoleID)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	token, err := utils.GenerateAccessToken(user, roleName, perms, version, sessionID)
+	if err != nil {
+		return "", "", nil, err
+	}
+	return token, roleName, perms, nil
+}
+
+// startSession membuat baris sessions baru (keluarga refresh token baru)
+// beserta access + refresh token pertamanya.
+func (s *AuthService) startSession(c *fiber.Ctx, user *models.User) (*tokenPair, error) {
+	sessionID := uuid.New().String()
+
+	refreshToken, refreshClaims, err := utils.GenerateRefreshToken(user, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	session := &models.Session{
+		ID:         sessionID,
+		UserID:     user.ID,
+		RefreshJTI: refreshClaims.TokenID(),
+		UserAgent:  c.Get(fiber.HeaderUserAgent),
+		IPAddress:  c.IP(),
+		ExpiresAt:  refreshClaims.ExpiresAt.Time,
+	}
+	if err := s.SessionRepo.Create(session); err != nil {
+		return nil, err
+	}
+
+	accessToken, roleName, perms, err := s.signAccessToken(user, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		SessionID:    sessionID,
+		RoleName:     roleName,
+		Permissions:  perms,
+	}, nil
+}
+
+// revokeSession menandai session di DB dan mematikan access token yang masih beredar
+func (s *AuthService) revokeSession(session *models.Session, reason string) {
+	if err := s.SessionRepo.Revoke(session.ID, reason); err != nil {
+		log.Printf("[WARN] revoke session %s: %v", session.ID, err)
+	}
+
+	// access token paling lama hidup selama AccessTokenTTL sejak diterbitkan
+	if err := utils.RevokeSession(session.ID, time.Now().Add(utils.AccessTokenTTL())); err != nil {
+		log.Printf("[WARN] revoke session %s tokens: %v", session.ID, err)
+	}
+}
+
+// revokeUserSessions me-revoke semua session user kecuali exceptID
+func (s *AuthService) revokeUserSessions(userID, exceptID, reason string) (int, error) {
+	ids, err := s.SessionRepo.RevokeAllByUser(userID, exceptID, reason)
+	if err != nil {
+		return 0, err
+	}
+
+	exp := time.Now().Add(utils.AccessTokenTTL())
+	for _, id := range ids {
+		if err := utils.RevokeSession(id, exp); err != nil {
+			log.Printf("[WARN] revoke session %s tokens: %v", id, err)
+		}
+	}
+	return len(ids), nil
+}
+
+func sessionView(sess models.Session, currentID string) fiber.Map {
+	return fiber.Map{
+		"id":           sess.ID,
+		"user_agent":   sess.UserAgent,
+		"ip_address":   sess.IPAddress,
+		"created_at":   sess.CreatedAt,
+		"last_used_at": sess.LastUsedAt,
+		"expires_at":   sess.ExpiresAt,
+		"current":      sess.ID == currentID,
+	}
+}
+
+//
+// ======================= MY SESSIONS =======================
+//
+
+// GET /api/v1/auth/sessions
+func (s *AuthService) Sessions(c *fiber.Ctx) error {
+	claims := c.Locals("claims").(*utils.JWTClaims)
+
+	list, err := s.SessionRepo.ListActiveByUser(claims.ID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed get sessions"})
+	}
+
+	data := make([]fiber.Map, 0, len(list))
+	for _, sess := range list {
+		data = append(data, sessionView(sess, claims.SessionID))
+	}
+	return c.JSON(fiber.Map{"status": "success", "data": data})
+}
+
+// DELETE /api/v1/auth/sessions/:id
+func (s *AuthService) DeleteSession(c *fiber.Ctx) error {
+	claims := c.Locals("claims").(*utils.JWTClaims)
+
+	session, err := s.SessionRepo.FindByID(c.Params("id"))
+	if err != nil || session.UserID != claims.ID {
+		return c.Status(404).JSON(fiber.Map{"error": "session not found"})
+	}
+
+	s.revokeSession(session, "revoked by user")
+	return c.JSON(fiber.Map{"status": "success", "message": "session revoked"})
+}
+
+// POST /api/v1/auth/logout-all
+func (s *AuthService) LogoutAll(c *fiber.Ctx) error {
+	claims := c.Locals("claims").(*utils.JWTClaims)
+
+	n, err := s.revokeUserSessions(claims.ID, "", "logout all")
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed revoke sessions"})
+	}
+
+	// token yang sedang dipakai juga ikut mati (misal token lama tanpa sid)
+	if err := utils.RevokeToken(claims.TokenID(), claims.ExpiresAt.Time); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed revoke token"})
+	}
+
+	s.recordClaimsEvent(c, models.AuthEventLogoutAll, claims, fmt.Sprintf("revoked %d sessions", n))
+
+	if s.cookieConfig.Enabled {
+		middleware.ClearAuthCookies(c, s.cookieConfig)
+	}
+
+	return c.JSON(fiber.Map{"status": "success", "message": "Logout success", "revoked_sessions": n})
+}
+
+//
+// ======================= USER SESSIONS (ADMIN) =======================
+//
+
+// GET /api/v1/users/:id/sessions
+func (s *AuthService) UserSessions(c *fiber.Ctx) error {
+	if _, err := s.AuthRepo.GetProfile(c.Params("id")); err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "user not found"})
+	}
+	list, err := s.SessionRepo.ListActiveByUser(c.Params("id"))
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed get sessions"})
+	}
+
+	data := make([]fiber.Map, 0, len(list))
+	for _, sess := range list {
+		data = append(data, sessionView(sess, ""))
+	}
+	return c.JSON(fiber.Map{"status": "success", "data": data})
+}
+
+// DELETE /api/v1/users/:id/sessions (force logout semua perangkat)
+func (s *AuthService) DeleteUserSessions(c *fiber.Ctx) error {
+	if _, err := s.AuthRepo.GetProfile(c.Params("id")); err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "user not found"})
+	}
+	n, err := s.revokeUserSessions(c.Params("id"), "", "revoked by admin")
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed revoke sessions"})
+	}
+	return c.JSON(fiber.Map{"status": "success", "revoked_sessions": n})
+}
+
+// DELETE /api/v1/users/:id/sessions/:sessionId
+func (s *AuthService) DeleteUserSession(c *fiber.Ctx) error {
+	session, err := s.SessionRepo.FindByID(c.Params("sessionId"))
+	if err != nil || session.UserID != c.Params("id") {
+		return c.Status(404).JSON(fiber.Map{"error": "session not found"})
+	}
+
+	s.revokeSession(session, "revoked by admin")
+	return c.JSON(fiber.Map{"status": "success", "message": "session revoked"})
+}
@@ -0,0 +1,179 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"os"
+	"strings"
+
+	"achievements-uas/app/models"
+	"achievements-uas/app/repository"
+	"achievements-uas/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// =====================================================
+// AUTHENTICATOR – backend verifikasi password
+// =====================================================
+// "local" = bcrypt di tabel users, "ldap" = bind ke direktori fakultas.
+// Backend dipilih per user (users.auth_source) atau, jika kosong, per role
+// lewat AUTH_SOURCE_BY_ROLE (misal: "Dosen Wali=ldap"). Default: local.
+
+const (
+	AuthSourceLocal = "local"
+	AuthSourceLDAP  = "ldap"
+)
+
+var ErrInvalidCredentials = errors.New("invalid credentials")
+
+// DirectoryProfile adalah atribut dari backend eksternal yang disinkronkan
+// ke users / lecturers setelah login berhasil (nil untuk backend lokal).
+type DirectoryProfile struct {
+	FullName   string
+	Email      string
+	Department string
+}
+
+type Authenticator interface {
+	Name() string
+	// Authenticate mengembalikan ErrInvalidCredentials jika password salah;
+	// error lain berarti backend tidak bisa dihubungi.
+	Authenticate(user *models.User, password string) (*DirectoryProfile, error)
+}
+
+// ---------- bcrypt / Postgres ----------
+
+type LocalAuthenticator struct {
+	AuthRepo *repository.AuthRepository
+}
+
+func (a *LocalAuthenticator) Name() string { return AuthSourceLocal }
+
+func (a *LocalAuthenticator) Authenticate(user *models.User, password string) (*DirectoryProfile, error) {
+	hash := user.PasswordHash
+	if hash == "" {
+		var err error
+		if hash, err = a.AuthRepo.GetPasswordHash(user.ID); err != nil {
+			return nil, err
+		}
+	}
+	if !utils.VerifyPassword(hash, password) {
+		return nil, ErrInvalidCredentials
+	}
+	return nil, nil
+}
+
+// ---------- LDAP / Active Directory ----------
+
+type LDAPAuthenticator struct {
+	Config utils.LDAPConfig
+}
+
+func (a *LDAPAuthenticator) Name() string { return AuthSourceLDAP }
+
+func (a *LDAPAuthenticator) Authenticate(user *models.User, password string) (*DirectoryProfile, error) {
+	entry, err := utils.LDAPAuthenticate(a.Config, user.Username, password)
+	if errors.Is(err, utils.ErrLDAPInvalidCredentials) {
+		return nil, ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &DirectoryProfile{
+		FullName:   entry.FullName,
+		Email:      strings.ToLower(entry.Email),
+		Department: entry.Department,
+	}, nil
+}
+
+// defaultAuthenticators: LDAP hanya didaftarkan jika LDAP_URL & LDAP_BASE_DN diisi
+func defaultAuthenticators(authRepo *repository.AuthRepository) map[string]Authenticator {
+	list := map[string]Authenticator{
+		AuthSourceLocal: &LocalAuthenticator{AuthRepo: authRepo},
+	}
+	if cfg := utils.LDAPConfigFromEnv(); cfg.Enabled() {
+		list[AuthSourceLDAP] = &LDAPAuthenticator{Config: cfg}
+	}
+	return list
+}
+
+// authSourceFor menentukan backend untuk user: kolom auth_source, lalu role
+func (s *AuthService) authSourceFor(user *models.User) string {
+	if user.AuthSource != "" {
+		return user.AuthSource
+	}
+
+	rules := os.Getenv("AUTH_SOURCE_BY_ROLE")
+	if rules == "" {
+		return AuthSourceLocal
+	}
+	roleName, err := s.RoleRepo.GetNameByID(user.RoleID)
+	if err != nil {
+		return AuthSourceLocal
+	}
+	for _, rule := range strings.Split(rules, ",") {
+		parts := strings.SplitN(rule, "=", 2)
+		if len(parts) == 2 && strings.TrimSpace(parts[0]) == roleName {
+			return strings.TrimSpace(parts[1])
+		}
+	}
+	return AuthSourceLocal
+}
+
+// authenticate memverifikasi password lewat backend milik user dan
+// menyinkronkan atribut direktori jika ada.
+func (s *AuthService) authenticate(user *models.User, password string) error {
+	source := s.authSourceFor(user)
+	auth, ok := s.Authenticators[source]
+	if !ok {
+		log.Printf("[WARN] user %s uses unavailable auth source %q", user.ID, source)
+		return errors.New("auth source " + source + " is not available")
+	}
+
+	profile, err := auth.Authenticate(user, password)
+	if err != nil {
+		return err
+	}
+
+	if profile != nil {
+		if err := s.AuthRepo.SyncDirectoryProfile(user.ID, profile.FullName, profile.Email, profile.Department); err != nil {
+			log.Printf("[WARN] sync directory profile %s: %v", user.ID, err)
+		} else {
+			if profile.FullName != "" {
+				user.FullName = profile.FullName
+			}
+			if profile.Email != "" {
+				user.Email = profile.Email
+			}
+		}
+	}
+	return nil
+}
+
+// PUT /api/v1/users/:id/auth-source  {"auth_source": "local" | "ldap" | ""}
+// "" berarti backend ditentukan oleh role (AUTH_SOURCE_BY_ROLE)
+func (s *AuthService) SetUserAuthSource(c *fiber.Ctx) error {
+	var body struct {
+		AuthSource string `json:"auth_source"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid input"})
+	}
+
+	if body.AuthSource != "" {
+		if _, ok := s.Authenticators[body.AuthSource]; !ok {
+			return c.Status(400).JSON(fiber.Map{"error": "auth source is not available: " + body.AuthSource})
+		}
+	}
+
+	if err := s.AuthRepo.SetAuthSource(c.Params("id"), body.AuthSource); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.Status(404).JSON(fiber.Map{"error": "user not found"})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "failed update auth source"})
+	}
+
+	return c.JSON(fiber.Map{"status": "success", "auth_source": body.AuthSource})
+}
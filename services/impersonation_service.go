@@ -0,0 +1,221 @@
+package services
+
+import (
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"achievements-uas/app/models"
+	"achievements-uas/app/repository"
+	"achievements-uas/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// =====================================================
+// ADMIN IMPERSONATION ("LOGIN SEBAGAI")
+// =====================================================
+// Admin mendapat access token singkat milik user target dengan klaim "act"
+// berisi admin asli. Token ini hanya boleh membaca (GET), dan setiap request
+// yang memakainya dicatat di impersonation_audit.
+
+const impersonationStopPath = "/auth/impersonation/stop"
+
+// Logout dengan token impersonation akan me-revoke session milik target user
+var impersonationBlockedPaths = []string{"/auth/logout", "/auth/logout-all"}
+
+type ImpersonationService struct {
+	Repo     *repository.ImpersonationRepository
+	AuthRepo *repository.AuthRepository
+	RoleRepo *repository.RoleRepository
+}
+
+func NewImpersonationService(
+	repo *repository.ImpersonationRepository,
+	authRepo *repository.AuthRepository,
+	roleRepo *repository.RoleRepository,
+) *ImpersonationService {
+	return &ImpersonationService{Repo: repo, AuthRepo: authRepo, RoleRepo: roleRepo}
+}
+
+func impersonationTTL() time.Duration {
+	if d, err := time.ParseDuration(os.Getenv("IMPERSONATION_TTL")); err == nil && d > 0 {
+		return d
+	}
+	return 15 * time.Minute
+}
+
+// POST /api/v1/users/:id/impersonate
+func (s *ImpersonationService) Start(c *fiber.Ctx) error {
+	claims := c.Locals("claims").(*utils.JWTClaims)
+	targetID := c.Params("id")
+
+	var body struct {
+		Reason string `json:"reason"`
+	}
+	if err := c.BodyParser(&body); err != nil || strings.TrimSpace(body.Reason) == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "reason is required"})
+	}
+
+	if claims.IsImpersonation() {
+		return c.Status(403).JSON(fiber.Map{"error": "cannot impersonate while impersonating"})
+	}
+	if targetID == claims.ID {
+		return c.Status(400).JSON(fiber.Map{"error": "cannot impersonate yourself"})
+	}
+
+	target, err := s.AuthRepo.GetProfile(targetID)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "user not found"})
+	}
+	if !target.IsActive {
+		return c.Status(400).JSON(fiber.Map{"error": "user is inactive"})
+	}
+
+	perms, version, err := utils.RolePermissions(target.RoleID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed load permissions"})
+	}
+	// akun dengan hak admin tidak boleh di-impersonate (mencegah eskalasi)
+	privileged := &utils.JWTClaims{Permissions: perms}
+	if privileged.HasPermission(models.PermUserManage, models.PermUserImpersonate, models.PermRoleManage) {
+		return c.Status(403).JSON(fiber.Map{"error": "privileged accounts cannot be impersonated"})
+	}
+
+	roleName, err := s.RoleRepo.GetNameByID(target.RoleID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed load role"})
+	}
+
+	// tidak boleh hidup lebih lama dari token admin sendiri
+	ttl := impersonationTTL()
+	if claims.ExpiresAt != nil {
+		if left := time.Until(claims.ExpiresAt.Time); left < ttl {
+			ttl = left
+		}
+	}
+
+	impersonationID := uuid.New().String()
+	token, tokenClaims, err := utils.GenerateImpersonationToken(target, roleName, perms, version, claims, impersonationID, ttl)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed generate token"})
+	}
+
+	session := &models.ImpersonationSession{
+		ID:           impersonationID,
+		AdminID:      claims.ID,
+		TargetUserID: target.ID,
+		Reason:       body.Reason,
+		IPAddress:    c.IP(),
+		ExpiresAt:    tokenClaims.ExpiresAt.Time,
+	}
+	if err := s.Repo.Create(session); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed record impersonation"})
+	}
+
+	log.Printf("[AUDIT] admin %s impersonates user %s (%s)", claims.ID, target.ID, body.Reason)
+
+	return c.Status(201).JSON(fiber.Map{
+		"status":           "success",
+		"access_token":     token,
+		"expires_in":       int(ttl.Seconds()),
+		"impersonation_id": session.ID,
+		"impersonating": fiber.Map{
+			"id":       target.ID,
+			"username": target.Username,
+			"role":     roleName,
+		},
+	})
+}
+
+// POST /api/v1/auth/impersonation/stop (dipanggil dengan token impersonation)
+func (s *ImpersonationService) Stop(c *fiber.Ctx) error {
+	claims := c.Locals("claims").(*utils.JWTClaims)
+	if !claims.IsImpersonation() {
+		return c.Status(400).JSON(fiber.Map{"error": "not an impersonation token"})
+	}
+
+	if err := utils.RevokeSession(claims.SessionID, claims.ExpiresAt.Time); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed revoke token"})
+	}
+	if _, err := s.Repo.End(claims.SessionID); err != nil {
+		log.Printf("[WARN] end impersonation %s: %v", claims.SessionID, err)
+	}
+
+	return c.JSON(fiber.Map{"status": "success", "message": "impersonation ended"})
+}
+
+// Guard dipasang setelah AuthRequired: memblokir aksi yang mengubah data
+// selama impersonation dan mencatat setiap request ke audit log.
+func (s *ImpersonationService) Guard(c *fiber.Ctx) error {
+	claims, ok := c.Locals("claims").(*utils.JWTClaims)
+	if !ok || !claims.IsImpersonation() {
+		return c.Next()
+	}
+
+	for _, p := range impersonationBlockedPaths {
+		if strings.HasSuffix(c.Path(), p) {
+			s.audit(c, claims, fiber.StatusForbidden, true)
+			return c.Status(403).JSON(fiber.Map{
+				"error":   "Forbidden",
+				"message": "Gunakan " + impersonationStopPath + " untuk mengakhiri impersonation",
+			})
+		}
+	}
+
+	method := c.Method()
+	readOnly := method == fiber.MethodGet || method == fiber.MethodHead || method == fiber.MethodOptions
+	if !readOnly && !strings.HasSuffix(c.Path(), impersonationStopPath) {
+		s.audit(c, claims, fiber.StatusForbidden, true)
+		return c.Status(403).JSON(fiber.Map{
+			"error":   "Forbidden",
+			"message": "Aksi yang mengubah data tidak diizinkan selama impersonation",
+		})
+	}
+
+	err := c.Next()
+	s.audit(c, claims, c.Response().StatusCode(), false)
+	return err
+}
+
+func (s *ImpersonationService) audit(c *fiber.Ctx, claims *utils.JWTClaims, status int, blocked bool) {
+	entry := &models.ImpersonationAudit{
+		ID:              uuid.New().String(),
+		ImpersonationID: claims.SessionID,
+		AdminID:         claims.Actor.ID,
+		TargetUserID:    claims.ID,
+		Method:          c.Method(),
+		Path:            c.OriginalURL(),
+		StatusCode:      status,
+		Blocked:         blocked,
+		IPAddress:       c.IP(),
+	}
+	if err := s.Repo.RecordRequest(entry); err != nil {
+		log.Printf("[WARN] record impersonation audit: %v", err)
+	}
+}
+
+// GET /api/v1/impersonations?admin_id=&target_user_id=&limit=
+func (s *ImpersonationService) List(c *fiber.Ctx) error {
+	limit := c.QueryInt("limit", 50)
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+
+	list, err := s.Repo.List(c.Query("admin_id"), c.Query("target_user_id"), limit)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed get impersonations"})
+	}
+	return c.JSON(fiber.Map{"status": "success", "data": list})
+}
+
+// GET /api/v1/impersonations/:id/requests
+func (s *ImpersonationService) Requests(c *fiber.Ctx) error {
+	list, err := s.Repo.ListRequests(c.Params("id"))
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed get impersonation requests"})
+	}
+	return c.JSON(fiber.Map{"status": "success", "data": list})
+}
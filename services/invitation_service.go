@@ -0,0 +1,146 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"achievements-uas/app/models"
+	"achievements-uas/app/repository"
+	"achievements-uas/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// =====================================================
+// UNDANGAN AKTIVASI AKUN
+// =====================================================
+// Admin membuat user dengan invite=true: akun nonaktif, password acak,
+// dan user menerima link untuk mengatur password sendiri.
+
+func invitationTTL() time.Duration {
+	d, err := time.ParseDuration(os.Getenv("INVITATION_TTL"))
+	if err != nil {
+		return 72 * time.Hour
+	}
+	return d
+}
+
+type InvitationService struct {
+	Repo      *repository.InvitationRepository
+	AdminRepo *repository.AdminRepository
+	Mailer    utils.Mailer
+	Passwords *PasswordService
+}
+
+func NewInvitationService(
+	repo *repository.InvitationRepository,
+	adminRepo *repository.AdminRepository,
+	mailer utils.Mailer,
+	passwords *PasswordService,
+) *InvitationService {
+	return &InvitationService{
+		Repo:      repo,
+		AdminRepo: adminRepo,
+		Mailer:    mailer,
+		Passwords: passwords,
+	}
+}
+
+// Send membuat token baru (undangan lama otomatis dibatalkan) dan mengirim email
+func (s *InvitationService) Send(user *models.User, createdBy string) (*models.UserInvitation, error) {
+	plain, hash, err := utils.GenerateOneTimeToken()
+	if err != nil {
+		return nil, err
+	}
+
+	ttl := invitationTTL()
+	inv := &models.UserInvitation{
+		ID:        uuid.New().String(),
+		UserID:    user.ID,
+		ExpiresAt: time.Now().Add(ttl),
+	}
+	if createdBy != "" {
+		inv.CreatedBy = &createdBy
+	}
+	if err := s.Repo.Create(inv, hash); err != nil {
+		return nil, err
+	}
+
+	link := fmt.Sprintf("%s/activate?token=%s", os.Getenv("APP_BASE_URL"), plain)
+	mailBody := fmt.Sprintf(
+		"Halo %s,\n\nAkun anda dengan username %s telah dibuat. Gunakan link berikut untuk mengatur password dan mengaktifkan akun (berlaku %s):\n%s",
+		user.FullName, user.Username, ttl, link,
+	)
+	if err := s.Mailer.Send(user.Email, "Aktivasi Akun", mailBody); err != nil {
+		return nil, err
+	}
+	return inv, nil
+}
+
+// POST /api/v1/users/:id/invitation/resend
+func (s *InvitationService) Resend(c *fiber.Ctx) error {
+	user, err := s.AdminRepo.GetByID(c.Params("id"))
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "user not found"})
+	}
+
+	// hanya akun yang belum pernah aktif lewat undangan
+	if user.IsActive || user.InvitationStatus == "" || user.InvitationStatus == models.InvitationAccepted {
+		return c.Status(409).JSON(fiber.Map{"error": "user has no pending invitation"})
+	}
+	if user.Email == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "user has no email"})
+	}
+
+	claims := c.Locals("claims").(*utils.JWTClaims)
+	inv, err := s.Send(user, claims.ID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed send invitation"})
+	}
+
+	return c.JSON(fiber.Map{
+		"status": "success",
+		"data":   fiber.Map{"status": models.InvitationPending, "expires_at": inv.ExpiresAt},
+	})
+}
+
+// POST /api/v1/auth/activate
+func (s *InvitationService) Activate(c *fiber.Ctx) error {
+	var body struct {
+		Token    string `json:"token"`
+		Password string `json:"password"`
+	}
+	if err := c.BodyParser(&body); err != nil || body.Token == "" || body.Password == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "token and password are required"})
+	}
+
+	tokenHash := utils.HashOneTimeToken(body.Token)
+
+	// validasi password dulu agar token tidak hangus karena password ditolak policy
+	userID, err := s.Repo.FindValid(tokenHash)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid or expired invitation"})
+	}
+	if err := s.Passwords.Validate(userID, body.Password); err != nil {
+		return policyErrorResponse(c, err)
+	}
+
+	hash, err := utils.HashPassword(body.Password)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed hash password"})
+	}
+
+	if userID, err = s.Repo.Accept(tokenHash, hash); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.Status(400).JSON(fiber.Map{"error": "invalid or expired invitation"})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "failed activate account"})
+	}
+	s.Passwords.Record(userID, hash)
+
+	return c.JSON(fiber.Map{"status": "success", "message": "account activated, please login"})
+}
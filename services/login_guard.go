@@ -0,0 +1,236 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"strconv"
+	"time"
+
+	"achievements-uas/app/models"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// =====================================================
+// BRUTE-FORCE PROTECTION (per akun & per IP)
+// =====================================================
+// - setelah LOGIN_BACKOFF_AFTER kali gagal, jeda antar percobaan naik eksponensial
+// - setelah LOGIN_LOCKOUT_THRESHOLD kali gagal, akun dikunci LOGIN_LOCKOUT_DURATION
+// - per IP: backoff eksponensial setelah LOGIN_IP_BACKOFF_AFTER kali gagal dalam
+//   LOGIN_IP_WINDOW, dan diblokir penuh setelah LOGIN_IP_MAX_FAILURES
+
+type loginGuardConfig struct {
+	BackoffAfter     int
+	BackoffBase      time.Duration
+	BackoffMax       time.Duration
+	LockoutThreshold int
+	LockoutDuration  time.Duration
+	IPBackoffAfter   int
+	IPMaxFailures    int
+	IPWindow         time.Duration
+}
+
+func envIntDefault(key string, def int) int {
+	v, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || v <= 0 {
+		return def
+	}
+	return v
+}
+
+func envDurationDefault(key string, def time.Duration) time.Duration {
+	d, err := time.ParseDuration(os.Getenv(key))
+	if err != nil || d <= 0 {
+		return def
+	}
+	return d
+}
+
+func loadLoginGuardConfig() loginGuardConfig {
+	return loginGuardConfig{
+		BackoffAfter:     envIntDefault("LOGIN_BACKOFF_AFTER", 3),
+		BackoffBase:      envDurationDefault("LOGIN_BACKOFF_BASE", time.Second),
+		BackoffMax:       envDurationDefault("LOGIN_BACKOFF_MAX", 5*time.Minute),
+		LockoutThreshold: envIntDefault("LOGIN_LOCKOUT_THRESHOLD", 10),
+		LockoutDuration:  envDurationDefault("LOGIN_LOCKOUT_DURATION", 15*time.Minute),
+		// IP lebih longgar dari akun karena satu lab bisa berbagi IP
+		IPBackoffAfter:   envIntDefault("LOGIN_IP_BACKOFF_AFTER", 10),
+		IPMaxFailures:    envIntDefault("LOGIN_IP_MAX_FAILURES", 50),
+		IPWindow:         envDurationDefault("LOGIN_IP_WINDOW", 15*time.Minute),
+	}
+}
+
+// backoffDelay: base * 2^(failures-after), dibatasi max
+func (cfg loginGuardConfig) backoffDelay(failures, after int) time.Duration {
+	if failures < after {
+		return 0
+	}
+	delay := time.Duration(float64(cfg.BackoffBase) * math.Pow(2, float64(failures-after)))
+	if delay > cfg.BackoffMax || delay <= 0 {
+		return cfg.BackoffMax
+	}
+	return delay
+}
+
+func retryAfter(c *fiber.Ctx, until time.Time) {
+	secs := int(math.Ceil(time.Until(until).Seconds()))
+	if secs < 1 {
+		secs = 1
+	}
+	c.Set(fiber.HeaderRetryAfter, strconv.Itoa(secs))
+}
+
+// recordAttempt menyimpan percobaan login, kegagalan menyimpan tidak menggagalkan login
+func (s *AuthService) recordAttempt(c *fiber.Ctx, identifier string, userID *string, success bool, reason string) {
+	err := s.AttemptRepo.Record(&models.LoginAttempt{
+		ID:         uuid.New().String(),
+		Identifier: identifier,
+		UserID:     userID,
+		IPAddress:  c.IP(),
+		UserAgent:  c.Get(fiber.HeaderUserAgent),
+		Success:    success,
+		Reason:     reason,
+	})
+	if err != nil {
+		log.Printf("[WARN] record login attempt: %v", err)
+	}
+
+	// login berhasil dicatat di completeLogin (setelah MFA & session dibuat)
+	if !success {
+		ev := authEvent{Type: models.AuthEventLogin, Identifier: identifier, Detail: reason}
+		if userID != nil {
+			ev.UserID = *userID
+		}
+		s.recordEvent(c, ev)
+	}
+}
+
+// checkIPThrottle menulis response 429 dan mengembalikan true jika IP sedang diblokir
+func (s *AuthService) checkIPThrottle(c *fiber.Ctx, identifier string) (bool, error) {
+	cfg := s.guardConfig
+	count, last, err := s.AttemptRepo.IPFailures(c.IP(), time.Now().Add(-cfg.IPWindow))
+	if err != nil || last == nil {
+		return false, nil
+	}
+
+	until := last.Add(cfg.backoffDelay(count, cfg.IPBackoffAfter))
+	if count >= cfg.IPMaxFailures {
+		until = last.Add(cfg.IPWindow)
+	}
+
+	if until.After(time.Now()) {
+		s.recordAttempt(c, identifier, nil, false, models.LoginReasonIPThrottled)
+		retryAfter(c, until)
+		return true, c.Status(429).JSON(fiber.Map{
+			"error":       "too many failed login attempts from this address",
+			"retry_after": until,
+		})
+	}
+	return false, nil
+}
+
+// checkAccountLock menulis response 423/429 dan mengembalikan true jika akun terkunci / dalam backoff
+func (s *AuthService) checkAccountLock(c *fiber.Ctx, identifier string, user *models.User) (bool, error) {
+	lock, err := s.AttemptRepo.GetLockout(user.ID)
+	if err != nil {
+		log.Printf("[WARN] get lockout %s: %v", user.ID, err)
+		return false, nil
+	}
+
+	if lock.LockedUntil != nil && lock.LockedUntil.After(time.Now()) {
+		s.recordAttempt(c, identifier, &user.ID, false, models.LoginReasonAccountLocked)
+		retryAfter(c, *lock.LockedUntil)
+		return true, c.Status(423).JSON(fiber.Map{
+			"error":        "account temporarily locked",
+			"locked_until": lock.LockedUntil,
+		})
+	}
+
+	if lock.LastFailedAt != nil {
+		until := lock.LastFailedAt.Add(s.guardConfig.backoffDelay(lock.FailedCount, s.guardConfig.BackoffAfter))
+		if until.After(time.Now()) {
+			s.recordAttempt(c, identifier, &user.ID, false, models.LoginReasonBackoff)
+			retryAfter(c, until)
+			return true, c.Status(429).JSON(fiber.Map{
+				"error":       "too many failed login attempts, try again later",
+				"retry_after": until,
+			})
+		}
+	}
+	return false, nil
+}
+
+// registerFailure mencatat password salah dan mengunci akun jika perlu
+func (s *AuthService) registerFailure(c *fiber.Ctx, identifier string, user *models.User) {
+	lock, err := s.AttemptRepo.RegisterFailure(user.ID, s.guardConfig.LockoutThreshold, s.guardConfig.LockoutDuration)
+	if err != nil {
+		log.Printf("[WARN] register login failure %s: %v", user.ID, err)
+	}
+
+	reason := "invalid password"
+	if lock != nil && lock.LockedUntil != nil {
+		reason = "invalid password, account locked"
+	}
+	s.recordAttempt(c, identifier, &user.ID, false, reason)
+
+	if lock != nil && lock.LockedUntil != nil {
+		s.recordEvent(c, authEvent{
+			Type:       models.AuthEventLockout,
+			Success:    true,
+			UserID:     user.ID,
+			Identifier: identifier,
+			Detail:     fmt.Sprintf("locked until %s after %d failures", lock.LockedUntil.Format(time.RFC3339), lock.FailedCount),
+		})
+	}
+}
+
+// registerSuccess mereset counter gagal milik akun
+func (s *AuthService) registerSuccess(c *fiber.Ctx, identifier string, user *models.User) {
+	if err := s.AttemptRepo.ResetLockout(user.ID); err != nil {
+		log.Printf("[WARN] reset lockout %s: %v", user.ID, err)
+	}
+	s.recordAttempt(c, identifier, &user.ID, true, "")
+}
+
+//
+// ======================= ADMIN: LOCKOUT & ATTEMPTS =======================
+//
+
+// POST /api/v1/users/:id/unlock
+func (s *AuthService) UnlockUser(c *fiber.Ctx) error {
+	if _, err := s.AuthRepo.GetProfile(c.Params("id")); err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "user not found"})
+	}
+	if err := s.AttemptRepo.ResetLockout(c.Params("id")); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed unlock user"})
+	}
+	return c.JSON(fiber.Map{"status": "success", "message": "user unlocked"})
+}
+
+// GET /api/v1/users/:id/login-attempts
+func (s *AuthService) UserLoginAttempts(c *fiber.Ctx) error {
+	limit := c.QueryInt("limit", 50)
+	if limit <= 0 || limit > 500 {
+		limit = 50
+	}
+
+	userID := c.Params("id")
+	attempts, err := s.AttemptRepo.ListByUser(userID, limit)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed get login attempts"})
+	}
+
+	lock, err := s.AttemptRepo.GetLockout(userID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed get lockout status"})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"lockout": lock,
+		"data":    attempts,
+	})
+}
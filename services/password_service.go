@@ -0,0 +1,78 @@
+package services
+
+import (
+	"errors"
+	"log"
+
+	"achievements-uas/app/repository"
+	"achievements-uas/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// PasswordService menerapkan password policy yang sama untuk pembuatan user,
+// reset oleh admin, ganti password sendiri, dan forgot-password.
+type PasswordService struct {
+	HistoryRepo *repository.PasswordHistoryRepository
+}
+
+func NewPasswordService(historyRepo *repository.PasswordHistoryRepository) *PasswordService {
+	return &PasswordService{HistoryRepo: historyRepo}
+}
+
+// Validate mengecek policy, data pribadi user, dan riwayat password.
+// userID boleh kosong untuk user yang belum tersimpan (pakai extra sebagai data pribadi).
+func (p *PasswordService) Validate(userID, password string, extra ...string) error {
+	personal := extra
+	if userID != "" {
+		ids, err := p.HistoryRepo.GetIdentifiers(userID)
+		if err != nil {
+			return err
+		}
+		personal = append(personal, ids...)
+	}
+
+	if err := utils.ValidatePassword(password, personal...); err != nil {
+		return err
+	}
+
+	pol := utils.CurrentPasswordPolicy()
+	if userID == "" || pol.HistorySize <= 0 {
+		return nil
+	}
+
+	hashes, err := p.HistoryRepo.RecentHashes(userID, pol.HistorySize)
+	if err != nil {
+		return err
+	}
+	for _, h := range hashes {
+		if utils.VerifyPassword(h, password) {
+			return &utils.PasswordPolicyError{Violations: []string{"must not reuse a recent password"}}
+		}
+	}
+	return nil
+}
+
+// Record menyimpan hash baru ke riwayat, dipanggil setelah password tersimpan
+func (p *PasswordService) Record(userID, hash string) {
+	keep := utils.CurrentPasswordPolicy().HistorySize
+	if keep <= 0 {
+		return
+	}
+	if err := p.HistoryRepo.Add(uuid.New().String(), userID, hash, keep); err != nil {
+		log.Printf("[WARN] record password history for %s: %v", userID, err)
+	}
+}
+
+// policyErrorResponse mengubah error dari Validate menjadi response HTTP
+func policyErrorResponse(c *fiber.Ctx, err error) error {
+	var perr *utils.PasswordPolicyError
+	if errors.As(err, &perr) {
+		return c.Status(400).JSON(fiber.Map{
+			"error":      "password does not meet policy",
+			"violations": perr.Violations,
+		})
+	}
+	return c.Status(500).JSON(fiber.Map{"error": "failed validate password"})
+}
@@ -24,17 +24,13 @@ func (s *ReportService) Statistics(c *fiber.Ctx) error {
 	var achievements []models.Achievement
 	var err error
 
-	// Penentuan data berdasarkan Role (RBAC)
-	switch claims.Role {
-	case "Mahasiswa":
-		// Mengambil data NIM mahasiswa berdasarkan UserID di token
-		student, errInfo := s.StudentRepo.FindByUserID(claims.ID)
-		if errInfo != nil {
-			return c.Status(404).JSON(fiber.Map{"error": "Student profile not found"})
-		}
-		achievements, err = s.MongoRepo.FindByStudentID(ctx, student.StudentID)
+	// Penentuan cakupan data berdasarkan permission (RBAC)
+	switch {
+	case claims.HasPermission(models.PermReportReadAll):
+		// Admin bisa menarik seluruh data prestasi
+		achievements, err = s.MongoRepo.FindAll(ctx)
 
-	case "Dosen Wali":
+	case claims.HasPermission(models.PermReportReadAdvisee):
 		// Mengambil semua mahasiswa bimbingan dosen ini
 		students, errInfo := s.StudentRepo.FindByAdvisorID(claims.ID)
 		if errInfo != nil {
@@ -50,12 +46,16 @@ func (s *ReportService) Statistics(c *fiber.Ctx) error {
 			achievements, err = s.MongoRepo.FindByStudentIDs(ctx, nims)
 		}
 
-	case "Admin":
-		// Admin bisa menarik seluruh data prestasi
-		achievements, err = s.MongoRepo.FindAll(ctx)
+	case claims.HasPermission(models.PermReportReadOwn):
+		// Mengambil data NIM mahasiswa berdasarkan UserID di token
+		student, errInfo := s.StudentRepo.FindByUserID(claims.ID)
+		if errInfo != nil {
+			return c.Status(404).JSON(fiber.Map{"error": "Student profile not found"})
+		}
+		achievements, err = s.MongoRepo.FindByStudentID(ctx, student.StudentID)
 
 	default:
-		return c.Status(403).JSON(fiber.Map{"error": "Forbidden: Role not recognized"})
+		return c.Status(403).JSON(fiber.Map{"error": "Forbidden: insufficient permissions"})
 	}
 
 	// Cek jika ada error saat fetch dari MongoDB
@@ -111,16 +111,15 @@ func (s *ReportService) StudentReport(c *fiber.Ctx) error {
 	claims := c.Locals("claims").(*utils.JWTClaims)
 	targetUserID := c.Params("id") // UUID dari URL
 
-	// Security: Mahasiswa tidak boleh intip laporan orang lain
-	if claims.Role == "Mahasiswa" && claims.ID != targetUserID {
-		return c.Status(403).JSON(fiber.Map{"error": "Forbidden: Access denied"})
-	}
-
-	// Security: Dosen hanya boleh lihat bimbingannya
-	if claims.Role == "Dosen Wali" {
-		isAdvisee, err := s.StudentRepo.IsAdvisorOf(claims.ID, targetUserID)
-		if err != nil || !isAdvisee {
-			return c.Status(403).JSON(fiber.Map{"error": "Forbidden: This student is not your advisee"})
+	// Security: read_all bebas, read_advisee hanya bimbingan, read_own hanya diri sendiri
+	if !claims.HasPermission(models.PermReportReadAll) {
+		allowed := claims.HasPermission(models.PermReportReadOwn) && claims.ID == targetUserID
+		if !allowed && claims.HasPermission(models.PermReportReadAdvisee) {
+			isAdvisee, err := s.StudentRepo.IsAdvisorOf(claims.ID, targetUserID)
+			allowed = err == nil && isAdvisee
+		}
+		if !allowed {
+			return c.Status(403).JSON(fiber.Map{"error": "Forbidden: Access denied"})
 		}
 	}
 
@@ -0,0 +1,273 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"achievements-uas/app/models"
+	"achievements-uas/app/repository"
+	"achievements-uas/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// =====================================================
+// ROLE & PERMISSION ADMINISTRATION
+// =====================================================
+// Role baru (misal: Kaprodi) cukup dibuat lewat API lalu diberi permission,
+// tanpa SQL manual. Role Admin dikelola sistem: selalu punya semua permission.
+
+type RoleAdminService struct {
+	RoleRepo       *repository.RoleRepository
+	PermissionRepo *repository.PermissionRepository
+	RolePermRepo   *repository.RolePermissionRepository
+}
+
+func NewRoleAdminService(
+	roleRepo *repository.RoleRepository,
+	permissionRepo *repository.PermissionRepository,
+	rolePermRepo *repository.RolePermissionRepository,
+) *RoleAdminService {
+	return &RoleAdminService{
+		RoleRepo:       roleRepo,
+		PermissionRepo: permissionRepo,
+		RolePermRepo:   rolePermRepo,
+	}
+}
+
+// ==============================================
+// GET /api/v1/roles
+// ==============================================
+func (s *RoleAdminService) GetAll(c *fiber.Ctx) error {
+	roles, err := s.RoleRepo.FindAll()
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed get roles"})
+	}
+	return c.JSON(fiber.Map{"status": "success", "data": roles})
+}
+
+// ==============================================
+// GET /api/v1/roles/:id
+// ==============================================
+func (s *RoleAdminService) GetByID(c *fiber.Ctx) error {
+	role, err := s.RoleRepo.FindByID(c.Params("id"))
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "role not found"})
+	}
+
+	perms, err := s.RolePermRepo.GetPermissionDetailsByRole(role.ID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed get role permissions"})
+	}
+
+	users, err := s.RoleRepo.CountUsers(role.ID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed count role users"})
+	}
+
+	return c.JSON(fiber.Map{
+		"status": "success",
+		"data": fiber.Map{
+			"role":        role,
+			"permissions": perms,
+			"user_count":  users,
+		},
+	})
+}
+
+// ==============================================
+// POST /api/v1/roles
+// ==============================================
+func (s *RoleAdminService) Create(c *fiber.Ctx) error {
+	var body struct {
+		Name        string   `json:"name"`
+		Description string   `json:"description"`
+		Permissions []string `json:"permission_ids"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid input"})
+	}
+	body.Name = strings.TrimSpace(body.Name)
+	if body.Name == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "name is required"})
+	}
+
+	if _, err := s.RoleRepo.FindByName(body.Name); err == nil {
+		return c.Status(409).JSON(fiber.Map{"error": "role name already exists"})
+	}
+
+	for _, pid := range body.Permissions {
+		if _, err := s.PermissionRepo.FindByID(pid); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "invalid permission_id: " + pid})
+		}
+	}
+
+	role := &models.Role{
+		ID:          uuid.New().String(),
+		Name:        body.Name,
+		Description: body.Description,
+		CreatedAt:   time.Now(),
+	}
+	if err := s.RoleRepo.Create(role); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed create role"})
+	}
+
+	for _, pid := range body.Permissions {
+		if _, err := s.RolePermRepo.Attach(models.RolePermission{RoleID: role.ID, PermissionID: pid}); err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "failed attach permission"})
+		}
+	}
+	utils.InvalidateRolePermissions(role.ID)
+
+	return c.Status(201).JSON(fiber.Map{"status": "success", "data": role})
+}
+
+// ==============================================
+// PUT /api/v1/roles/:id
+// ==============================================
+func (s *RoleAdminService) Update(c *fiber.Ctx) error {
+	role, err := s.RoleRepo.FindByID(c.Params("id"))
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "role not found"})
+	}
+
+	var body struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid input"})
+	}
+	body.Name = strings.TrimSpace(body.Name)
+	if body.Name == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "name is required"})
+	}
+
+	// nama role bawaan dipakai untuk seed permission & MFA_ENFORCED_ROLES
+	if role.Name == models.AdminRoleName && body.Name != role.Name {
+		return c.Status(409).JSON(fiber.Map{"error": "admin role cannot be renamed"})
+	}
+	if other, err := s.RoleRepo.FindByName(body.Name); err == nil && other.ID != role.ID {
+		return c.Status(409).JSON(fiber.Map{"error": "role name already exists"})
+	}
+
+	role.Name = body.Name
+	role.Description = body.Description
+	if err := s.RoleRepo.Update(role); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed update role"})
+	}
+
+	return c.JSON(fiber.Map{"status": "success", "data": role})
+}
+
+// ==============================================
+// DELETE /api/v1/roles/:id
+// ==============================================
+func (s *RoleAdminService) Delete(c *fiber.Ctx) error {
+	role, err := s.RoleRepo.FindByID(c.Params("id"))
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "role not found"})
+	}
+	if role.Name == models.AdminRoleName {
+		return c.Status(409).JSON(fiber.Map{"error": "admin role cannot be deleted"})
+	}
+
+	deleted, err := s.RoleRepo.Delete(role.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.Status(404).JSON(fiber.Map{"error": "role not found"})
+	}
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed delete role"})
+	}
+	if !deleted {
+		users, _ := s.RoleRepo.CountUsers(role.ID)
+		return c.Status(409).JSON(fiber.Map{
+			"error":      "role is still assigned to users",
+			"user_count": users,
+		})
+	}
+
+	utils.InvalidateRolePermissions(role.ID)
+	return c.JSON(fiber.Map{"status": "success", "message": "role deleted"})
+}
+
+// ==============================================
+// GET /api/v1/permissions
+// ==============================================
+func (s *RoleAdminService) GetPermissions(c *fiber.Ctx) error {
+	perms, err := s.PermissionRepo.FindAll()
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed get permissions"})
+	}
+	return c.JSON(fiber.Map{"status": "success", "data": perms})
+}
+
+// ==============================================
+// POST /api/v1/roles/:id/permissions
+// ==============================================
+func (s *RoleAdminService) AttachPermission(c *fiber.Ctx) error {
+	var body struct {
+		PermissionID string `json:"permission_id"`
+	}
+	if err := c.BodyParser(&body); err != nil || body.PermissionID == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "permission_id is required"})
+	}
+
+	rp, resp := s.rolePermission(c, body.PermissionID)
+	if rp == nil {
+		return resp
+	}
+
+	attached, err := s.RolePermRepo.Attach(*rp)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed attach permission"})
+	}
+	if !attached {
+		return c.Status(409).JSON(fiber.Map{"error": "permission already attached"})
+	}
+	utils.InvalidateRolePermissions(rp.RoleID)
+
+	return c.Status(201).JSON(fiber.Map{"status": "success", "data": rp})
+}
+
+// ==============================================
+// DELETE /api/v1/roles/:id/permissions/:permissionId
+// ==============================================
+func (s *RoleAdminService) DetachPermission(c *fiber.Ctx) error {
+	rp, resp := s.rolePermission(c, c.Params("permissionId"))
+	if rp == nil {
+		return resp
+	}
+
+	detached, err := s.RolePermRepo.Detach(*rp)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed detach permission"})
+	}
+	if !detached {
+		return c.Status(404).JSON(fiber.Map{"error": "permission is not attached to role"})
+	}
+	utils.InvalidateRolePermissions(rp.RoleID)
+
+	return c.JSON(fiber.Map{"status": "success", "message": "permission detached"})
+}
+
+// rolePermission memvalidasi role & permission dari request. Hasil nil
+// berarti request sudah dijawab dengan error.
+func (s *RoleAdminService) rolePermission(c *fiber.Ctx, permissionID string) (*models.RolePermission, error) {
+	role, err := s.RoleRepo.FindByID(c.Params("id"))
+	if err != nil {
+		return nil, c.Status(404).JSON(fiber.Map{"error": "role not found"})
+	}
+	if role.Name == models.AdminRoleName {
+		return nil, c.Status(409).JSON(fiber.Map{"error": "admin role always has every permission"})
+	}
+
+	if _, err := s.PermissionRepo.FindByID(permissionID); err != nil {
+		return nil, c.Status(404).JSON(fiber.Map{"error": "permission not found"})
+	}
+
+	return &models.RolePermission{RoleID: role.ID, PermissionID: permissionID}, nil
+}
@@ -0,0 +1,58 @@
+package services
+
+import (
+	"strings"
+
+	"achievements-uas/app/models"
+)
+
+// =====================================================
+// PENCOCOKAN RULE PRESTASI
+// =====================================================
+// Dipakai scoring rulebook dan approval chain: kriteria NULL = wildcard,
+// kriteria yang terisi harus sama (case-insensitive). Kandidat paling
+// spesifik (kriteria terisi terbanyak) menang, lalu priority tertinggi.
+
+type textCriterion struct {
+	want *string
+	got  string
+}
+
+// achievementCriteria: kriteria umum jenis & tingkat prestasi
+func achievementCriteria(achievementType, competitionLevel *string, ach *models.Achievement) []textCriterion {
+	return []textCriterion{
+		{achievementType, ach.AchievementType},
+		{competitionLevel, ach.Details.CompetitionLevel},
+	}
+}
+
+// matchCriteria mengembalikan jumlah kriteria terisi, false jika ada yang tidak cocok
+func matchCriteria(criteria []textCriterion) (int, bool) {
+	specificity := 0
+	for _, c := range criteria {
+		if c.want == nil {
+			continue
+		}
+		if !strings.EqualFold(strings.TrimSpace(*c.want), strings.TrimSpace(c.got)) {
+			return 0, false
+		}
+		specificity++
+	}
+	return specificity, true
+}
+
+// bestMatch mengembalikan index kandidat terbaik dari n kandidat, -1 jika tidak ada
+func bestMatch(n int, candidate func(i int) (specificity, priority int, ok bool)) int {
+	best, bestSpecificity, bestPriority := -1, -1, 0
+	for i := 0; i < n; i++ {
+		specificity, priority, ok := candidate(i)
+		if !ok {
+			continue
+		}
+		if best < 0 || specificity > bestSpecificity ||
+			(specificity == bestSpecificity && priority > bestPriority) {
+			best, bestSpecificity, bestPriority = i, specificity, priority
+		}
+	}
+	return best
+}
@@ -0,0 +1,304 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"achievements-uas/app/models"
+	"achievements-uas/app/repository"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// =====================================================
+// SCORING RULEBOOK
+// =====================================================
+// Poin prestasi ditentukan saat status menjadi 'verified' dari rule aktif
+// yang paling spesifik (kriteria terisi terbanyak), lalu priority tertinggi.
+// Tidak ada rule yang cocok = 0 poin.
+
+// matchScoringRule mengembalikan rule terbaik untuk prestasi, nil jika tidak ada
+func matchScoringRule(rules []models.ScoringRule, ach *models.Achievement) *models.ScoringRule {
+	i := bestMatch(len(rules), func(i int) (int, int, bool) {
+		specificity, ok := ruleMatches(&rules[i], ach)
+		return specificity, rules[i].Priority, ok
+	})
+	if i < 0 {
+		return nil
+	}
+	return &rules[i]
+}
+
+// ruleMatches: semua kriteria yang terisi harus sama (case-insensitive)
+func ruleMatches(rule *models.ScoringRule, ach *models.Achievement) (int, bool) {
+	d := ach.Details
+	criteria := append(achievementCriteria(rule.AchievementType, rule.CompetitionLevel, ach),
+		textCriterion{rule.MedalType, d.MedalType},
+		textCriterion{rule.PublicationType, d.PublicationType},
+		textCriterion{rule.Position, d.Position},
+	)
+	specificity, ok := matchCriteria(criteria)
+	if !ok {
+		return 0, false
+	}
+	if rule.Rank != nil {
+		if *rule.Rank != d.Rank {
+			return 0, false
+		}
+		specificity++
+	}
+	return specificity, true
+}
+
+// applyScore menghitung & menyimpan poin prestasi (MongoDB + PostgreSQL)
+func applyScore(ctx context.Context, mongoRepo *repository.AchievementMongoRepository,
+	pgRepo *repository.AchievementPostgresRepository, rules []models.ScoringRule, ach *models.Achievement) (*models.ScoringRule, error) {
+	rule := matchScoringRule(rules, ach)
+
+	points, ruleID := 0, ""
+	var pgRuleID *string
+	if rule != nil {
+		points, ruleID = rule.Points, rule.ID
+		pgRuleID = &rule.ID
+	}
+
+	if err := mongoRepo.SetScore(ctx, ach.ID, points, ruleID); err != nil {
+		return nil, err
+	}
+	if err := pgRepo.SetScore(ctx, ach.ID.Hex(), points, pgRuleID); err != nil {
+		return nil, err
+	}
+	ach.Points, ach.ScoringRuleID = points, ruleID
+	return rule, nil
+}
+
+// scoreAchievement dipanggil workflow saat prestasi menjadi 'verified'
+func (s *AchievementService) scoreAchievement(ctx context.Context, ach *models.Achievement) {
+	if s.ScoringRepo == nil {
+		return
+	}
+	rules, err := s.ScoringRepo.FindAll(true)
+	if err == nil {
+		_, err = applyScore(ctx, s.MongoRepo, s.PgRepo, rules, ach)
+	}
+	if err != nil {
+		// verifikasi tetap sah; poin bisa dihitung ulang lewat recompute
+		log.Printf("[WARN] score achievement %s: %v", ach.ID.Hex(), err)
+	}
+}
+
+type ScoringService struct {
+	RuleRepo  *repository.ScoringRuleRepository
+	MongoRepo *repository.AchievementMongoRepository
+	PgRepo    *repository.AchievementPostgresRepository
+}
+
+func NewScoringService(
+	ruleRepo *repository.ScoringRuleRepository,
+	mongoRepo *repository.AchievementMongoRepository,
+	pgRepo *repository.AchievementPostgresRepository,
+) *ScoringService {
+	return &ScoringService{
+		RuleRepo:  ruleRepo,
+		MongoRepo: mongoRepo,
+		PgRepo:    pgRepo,
+	}
+}
+
+// ==============================================
+// GET /api/v1/scoring-rules
+// ==============================================
+func (s *ScoringService) GetAll(c *fiber.Ctx) error {
+	rules, err := s.RuleRepo.FindAll(false)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed get scoring rules"})
+	}
+	return c.JSON(fiber.Map{"status": "success", "data": rules})
+}
+
+// ==============================================
+// GET /api/v1/scoring-rules/:id
+// ==============================================
+func (s *ScoringService) GetByID(c *fiber.Ctx) error {
+	rule, err := s.RuleRepo.FindByID(c.Params("id"))
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "scoring rule not found"})
+	}
+	return c.JSON(fiber.Map{"status": "success", "data": rule})
+}
+
+// scoringRuleInput: string kosong pada kriteria diperlakukan sebagai wildcard
+type scoringRuleInput struct {
+	Name             string  `json:"name"`
+	AchievementType  *string `json:"achievement_type"`
+	CompetitionLevel *string `json:"competition_level"`
+	Rank             *int    `json:"rank"`
+	MedalType        *string `json:"medal_type"`
+	PublicationType  *string `json:"publication_type"`
+	Position         *string `json:"position"`
+	Points           *int    `json:"points"`
+	Priority         int     `json:"priority"`
+	IsActive         *bool   `json:"is_active"`
+}
+
+func (in *scoringRuleInput) toRule(rule *models.ScoringRule) error {
+	in.Name = strings.TrimSpace(in.Name)
+	if in.Name == "" {
+		return errors.New("name is required")
+	}
+	if in.Points == nil || *in.Points < 0 {
+		return errors.New("points is required and must be >= 0")
+	}
+	if in.Rank != nil && *in.Rank <= 0 {
+		return errors.New("rank must be > 0")
+	}
+
+	rule.Name = in.Name
+	rule.AchievementType = optionalCriterion(in.AchievementType)
+	rule.CompetitionLevel = optionalCriterion(in.CompetitionLevel)
+	rule.Rank = in.Rank
+	rule.MedalType = optionalCriterion(in.MedalType)
+	rule.PublicationType = optionalCriterion(in.PublicationType)
+	rule.Position = optionalCriterion(in.Position)
+	rule.Points = *in.Points
+	rule.Priority = in.Priority
+	rule.IsActive = in.IsActive == nil || *in.IsActive
+	return nil
+}
+
+func optionalCriterion(v *string) *string {
+	if v == nil || strings.TrimSpace(*v) == "" {
+		return nil
+	}
+	t := strings.TrimSpace(*v)
+	return &t
+}
+
+// ==============================================
+// POST /api/v1/scoring-rules
+// ==============================================
+func (s *ScoringService) Create(c *fiber.Ctx) error {
+	var in scoringRuleInput
+	if err := c.BodyParser(&in); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid input"})
+	}
+
+	rule := &models.ScoringRule{ID: uuid.New().String()}
+	if err := in.toRule(rule); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err := s.RuleRepo.Create(rule); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed create scoring rule"})
+	}
+
+	rule.CreatedAt, rule.UpdatedAt = time.Now(), time.Now()
+	return c.Status(201).JSON(fiber.Map{"status": "success", "data": rule})
+}
+
+// ==============================================
+// PUT /api/v1/scoring-rules/:id
+// ==============================================
+func (s *ScoringService) Update(c *fiber.Ctx) error {
+	rule, err := s.RuleRepo.FindByID(c.Params("id"))
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "scoring rule not found"})
+	}
+
+	var in scoringRuleInput
+	if err := c.BodyParser(&in); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid input"})
+	}
+	if err := in.toRule(rule); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	if _, err := s.RuleRepo.Update(rule); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed update scoring rule"})
+	}
+
+	rule.UpdatedAt = time.Now()
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"data":    rule,
+		"message": "run POST /scoring-rules/recompute to apply to verified achievements",
+	})
+}
+
+// ==============================================
+// DELETE /api/v1/scoring-rules/:id
+// ==============================================
+func (s *ScoringService) Delete(c *fiber.Ctx) error {
+	deleted, err := s.RuleRepo.Delete(c.Params("id"))
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed delete scoring rule"})
+	}
+	if !deleted {
+		return c.Status(404).JSON(fiber.Map{"error": "scoring rule not found"})
+	}
+	return c.JSON(fiber.Map{"status": "success", "message": "scoring rule deleted"})
+}
+
+// ==============================================
+// POST /api/v1/scoring-rules/recompute
+// ==============================================
+// Menghitung ulang poin semua prestasi 'verified' setelah rulebook berubah.
+// ?dry_run=true hanya menampilkan perubahan tanpa menyimpan.
+func (s *ScoringService) Recompute(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+	dryRun := c.QueryBool("dry_run", false)
+
+	rules, err := s.RuleRepo.FindAll(true)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed get scoring rules"})
+	}
+	list, err := s.MongoRepo.FindByStatus(ctx, models.AchievementStatusVerified)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed get verified achievements"})
+	}
+
+	changes := []fiber.Map{}
+	failed := 0
+	for i := range list {
+		ach := &list[i]
+		oldPoints, oldRule := ach.Points, ach.ScoringRuleID
+
+		rule := matchScoringRule(rules, ach)
+		newPoints, newRule := 0, ""
+		if rule != nil {
+			newPoints, newRule = rule.Points, rule.ID
+		}
+		if newPoints == oldPoints && newRule == oldRule {
+			continue
+		}
+
+		if !dryRun {
+			if _, err := applyScore(ctx, s.MongoRepo, s.PgRepo, rules, ach); err != nil {
+				log.Printf("[WARN] recompute score %s: %v", ach.ID.Hex(), err)
+				failed++
+				continue
+			}
+		}
+		changes = append(changes, fiber.Map{
+			"id":              ach.ID.Hex(),
+			"student_id":      ach.StudentID,
+			"old_points":      oldPoints,
+			"new_points":      newPoints,
+			"scoring_rule_id": newRule,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status": "success",
+		"data": fiber.Map{
+			"dry_run":   dryRun,
+			"processed": len(list),
+			"changed":   len(changes),
+			"failed":    failed,
+			"changes":   changes,
+		},
+	})
+}
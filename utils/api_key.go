@@ -0,0 +1,137 @@
+package utils
+
+import (
+	"errors"
+	"log"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"achievements-uas/app/models"
+)
+
+// =====================================================
+// API KEY (MACHINE-TO-MACHINE)
+// =====================================================
+// Format key: "uas_<prefix>_<secret>". Hanya SHA-256 dari key utuh yang
+// disimpan. AuthRequired menerima header X-API-Key sebagai alternatif
+// Bearer JWT; permission = scopes milik key (tidak ikut role manapun).
+
+const (
+	APIKeyHeader    = "X-API-Key"
+	TokenTypeAPIKey = "api_key"
+	apiKeyPrefix    = "uas_"
+)
+
+// APIKeyStore diimplementasikan oleh APIKeyRepository.
+type APIKeyStore interface {
+	FindByHash(hash string) (*models.APIKey, error)
+	TouchLastUsed(id, ip string) error
+}
+
+var (
+	apiKeyStore APIKeyStore
+	apiKeyMux   sync.RWMutex
+
+	ErrAPIKeyInvalid   = errors.New("invalid api key")
+	ErrAPIKeyForbidden = errors.New("api key not allowed from this address")
+)
+
+// SetAPIKeyStore dipanggil sekali di main.go; tanpa store, X-API-Key ditolak.
+func SetAPIKeyStore(store APIKeyStore) {
+	apiKeyMux.Lock()
+	defer apiKeyMux.Unlock()
+	apiKeyStore = store
+}
+
+// GenerateAPIKey mengembalikan key plain (ditampilkan sekali), prefix & hash
+func GenerateAPIKey() (plain, prefix, hash string, err error) {
+	prefix, err = randomURLString(6)
+	if err != nil {
+		return "", "", "", err
+	}
+	secret, err := randomURLString(32)
+	if err != nil {
+		return "", "", "", err
+	}
+	// "_" dari base64url diganti agar prefix tetap bisa dipisah dengan aman
+	prefix = strings.NewReplacer("_", "x", "-", "y").Replace(prefix)
+	plain = apiKeyPrefix + prefix + "_" + secret
+	return plain, prefix, HashOneTimeToken(plain), nil
+}
+
+// AuthenticateAPIKey memvalidasi key (revoked, expiry, IP allowlist) dan
+// mengembalikan claims sintetis untuk dipakai handler seperti JWT biasa.
+func AuthenticateAPIKey(raw, ip string) (*JWTClaims, error) {
+	apiKeyMux.RLock()
+	store := apiKeyStore
+	apiKeyMux.RUnlock()
+
+	if store == nil || !strings.HasPrefix(raw, apiKeyPrefix) {
+		return nil, ErrAPIKeyInvalid
+	}
+
+	key, err := store.FindByHash(HashOneTimeToken(raw))
+	if err != nil {
+		return nil, ErrAPIKeyInvalid
+	}
+	if key.RevokedAt != nil || (key.ExpiresAt != nil && time.Now().After(*key.ExpiresAt)) {
+		return nil, ErrAPIKeyInvalid
+	}
+	if !IPAllowed(ip, key.AllowedIPs) {
+		return nil, ErrAPIKeyForbidden
+	}
+
+	// last_used cukup akurat per menit, hindari UPDATE di setiap request
+	if key.LastUsedAt == nil || time.Since(*key.LastUsedAt) > time.Minute || key.LastUsedIP != ip {
+		if err := store.TouchLastUsed(key.ID, ip); err != nil {
+			log.Printf("[WARN] touch api key %s: %v", key.ID, err)
+		}
+	}
+
+	return &JWTClaims{
+		ID:          key.ID,
+		Username:    "apikey:" + key.Name,
+		Role:        "api-key",
+		Permissions: key.Scopes,
+		TokenType:   TokenTypeAPIKey,
+	}, nil
+}
+
+// IPAllowed: allowlist kosong = semua IP; entry boleh IP tunggal atau CIDR
+func IPAllowed(ip string, allowlist []string) bool {
+	if len(allowlist) == 0 {
+		return true
+	}
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return false
+	}
+	for _, entry := range allowlist {
+		if strings.Contains(entry, "/") {
+			if _, cidr, err := net.ParseCIDR(entry); err == nil && cidr.Contains(addr) {
+				return true
+			}
+			continue
+		}
+		if other := net.ParseIP(entry); other != nil && other.Equal(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidIPEntry dipakai saat membuat key untuk memvalidasi allowlist
+func ValidIPEntry(entry string) bool {
+	if strings.Contains(entry, "/") {
+		_, _, err := net.ParseCIDR(entry)
+		return err == nil
+	}
+	return net.ParseIP(entry) != nil
+}
+
+// IsAPIKey true jika request diautentikasi dengan API key
+func (c *JWTClaims) IsAPIKey() bool {
+	return c.TokenType == TokenTypeAPIKey
+}
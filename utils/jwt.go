@@ -2,61 +2,198 @@ package utils
 
 import (
 	"achievements-uas/app/models"
+	"crypto/hmac"
+	"crypto/sha256"
 	"errors"
 	"os"
+	"strconv"
 	"time"
 
 	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+)
+
+// Jenis token (klaim "typ"). Setiap parser hanya menerima jenisnya sendiri.
+const (
+	TokenTypeAccess  = "access"
+	TokenTypeRefresh = "refresh"
+	TokenTypeMFA     = "mfa"
 )
 
 type JWTClaims struct {
 	ID          string   `json:"id"`
 	Username        string   `json:"name"`
-	Role        string   `json:"role"`
-	Permissions []string `json:"permissions"`
+	Role        string   `json:"role,omitempty"`
+	Permissions []string `json:"permissions,omitempty"`
+	// RoleID & PermVersion dipakai AuthRequired untuk memuat permission live
+	RoleID      string   `json:"rid,omitempty"`
+	PermVersion int64    `json:"pv,omitempty"`
+	// Actor terisi jika token adalah hasil impersonation (RFC 8693 "act")
+	Actor       *TokenActor `json:"act,omitempty"`
+	SessionID   string   `json:"sid,omitempty"`
+	TokenType   string   `json:"typ"`
+	// RegisteredClaims.ID dikirim sebagai klaim "jti" dan dipakai sebagai kunci revocation
 	jwt.RegisteredClaims
 }
 
+// TokenActor adalah admin asli di balik token impersonation
+type TokenActor struct {
+	ID        string `json:"sub"`
+	Username  string `json:"name"`
+	// SessionID = session admin; logout admin ikut mematikan token impersonation
+	SessionID string `json:"sid,omitempty"`
+}
+
+// IsImpersonation true jika token diterbitkan lewat "login sebagai"
+func (c *JWTClaims) IsImpersonation() bool {
+	return c.Actor != nil
+}
+
+// TokenID mengembalikan klaim jti (field ID milik JWTClaims sudah dipakai untuk user ID).
+func (c *JWTClaims) TokenID() string {
+	return c.RegisteredClaims.ID
+}
+
+// HasPermission mengecek apakah token membawa minimal satu permission yang diminta.
+func (c *JWTClaims) HasPermission(perms ...string) bool {
+	for _, have := range c.Permissions {
+		for _, want := range perms {
+			if have == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 var ErrTokenInvalid = errors.New("token invalid")
 
-func GenerateAccessToken(user *models.User, roleName string, permissions []string) (string, error) {
-    // Ambil durasi dari env (misal: 15m)
-    expireStr := os.Getenv("JWT_EXPIRE")
-    duration, err := time.ParseDuration(expireStr)
+// AccessTokenTTL membaca JWT_EXPIRE (format durasi, misal: 15m)
+func AccessTokenTTL() time.Duration {
+    duration, err := time.ParseDuration(os.Getenv("JWT_EXPIRE"))
     if err != nil {
-        duration = time.Hour * 24 // default 24 jam
+        return time.Hour * 24 // default 24 jam
     }
+    return duration
+}
 
-    claims := JWTClaims{
-        ID:          user.ID,
-        Role:        roleName,
-		Username:        user.Username,
-        Permissions: permissions,
-        RegisteredClaims: jwt.RegisteredClaims{
-            ExpiresAt: jwt.NewNumericDate(time.Now().Add(duration)),
-            IssuedAt:  jwt.NewNumericDate(time.Now()),
-        },
+// RefreshTokenTTL membaca JWT_REFRESH_EXPIRE_HOURS, default 7 hari
+func RefreshTokenTTL() time.Duration {
+    hours, err := strconv.Atoi(os.Getenv("JWT_REFRESH_EXPIRE_HOURS"))
+    if err != nil || hours <= 0 {
+        return 24 * time.Hour * 7
     }
+    return time.Duration(hours) * time.Hour
+}
+
+// =====================================================
+// ISSUER, AUDIENCE & SIGNING KEY
+// =====================================================
+func tokenIssuer() string {
+	if v := os.Getenv("JWT_ISSUER"); v != "" {
+		return v
+	}
+	return "achievements-uas"
+}
 
-    token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-    return token.SignedString([]byte(os.Getenv("JWT_SECRET")))
+func accessAudience() string {
+	if v := os.Getenv("JWT_AUDIENCE"); v != "" {
+		return v
+	}
+	return "achievements-uas-api"
 }
 
+// refresh & MFA token hanya dipakai endpoint internal, audience-nya tidak bisa diubah
+const (
+	refreshAudience = "achievements-uas-refresh"
+	mfaAudience     = "achievements-uas-mfa"
+)
 
-func ParseAccessToken(tokenStr string) (*JWTClaims, error) {
-	token, err := jwt.ParseWithClaims(tokenStr, &JWTClaims{}, func(t *jwt.Token) (interface{}, error) {
-		return []byte(os.Getenv("JWT_SECRET")), nil
-	})
+func accessSecret() ([]byte, error) {
+	secret := os.Getenv("JWT_SECRET")
+	if secret == "" {
+		return nil, errors.New("JWT_SECRET is not set")
+	}
+	return []byte(secret), nil
+}
+
+// refreshSecret memakai JWT_REFRESH_SECRET. Jika kosong, key diturunkan dari
+// JWT_SECRET lewat HMAC sehingga tetap berbeda dari key access token.
+func refreshSecret() ([]byte, error) {
+	if secret := os.Getenv("JWT_REFRESH_SECRET"); secret != "" {
+		return []byte(secret), nil
+	}
+	return derivedSecret("refresh-token-signing-key")
+}
+
+func derivedSecret(label string) ([]byte, error) {
+	base, err := accessSecret()
 	if err != nil {
-		return nil, ErrTokenInvalid
+		return nil, err
 	}
+	mac := hmac.New(sha256.New, base)
+	mac.Write([]byte(label))
+	return mac.Sum(nil), nil
+}
 
-	claims, ok := token.Claims.(*JWTClaims)
-	if !ok || !token.Valid {
-		return nil, ErrTokenInvalid
+func GenerateAccessToken(user *models.User, roleName string, permissions []string, permVersion int64, sessionID string) (string, error) {
+	claims := JWTClaims{
+		ID:          user.ID,
+		Role:        roleName,
+		Username:    user.Username,
+		Permissions: permissions,
+		RoleID:      user.RoleID,
+		PermVersion: permVersion,
+		SessionID:   sessionID,
+		TokenType:   TokenTypeAccess,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.New().String(),
+			Issuer:    tokenIssuer(),
+			Subject:   user.ID,
+			Audience:  jwt.ClaimStrings{accessAudience()},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(AccessTokenTTL())),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
 	}
 
-	return claims, nil
+	return signAccess(claims)
+}
+
+// GenerateImpersonationToken menerbitkan access token milik target user yang
+// membawa klaim act (admin asli). sid = id impersonation_sessions, sedangkan
+// session admin disimpan di act.sid agar logout admin ikut mematikan token ini.
+// Tidak ada refresh token untuk impersonation.
+func GenerateImpersonationToken(target *models.User, roleName string, permissions []string, permVersion int64,
+	actor *JWTClaims, impersonationID string, ttl time.Duration) (string, *JWTClaims, error) {
+	claims := &JWTClaims{
+		ID:          target.ID,
+		Role:        roleName,
+		Username:    target.Username,
+		Permissions: permissions,
+		RoleID:      target.RoleID,
+		PermVersion: permVersion,
+		SessionID:   impersonationID,
+		TokenType:   TokenTypeAccess,
+		Actor:       &TokenActor{ID: actor.ID, Username: actor.Username, SessionID: actor.SessionID},
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.New().String(),
+			Issuer:    tokenIssuer(),
+			Subject:   target.ID,
+			Audience:  jwt.ClaimStrings{accessAudience()},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+
+	signed, err := signAccess(claims)
+	if err != nil {
+		return "", nil, err
+	}
+	return signed, claims, nil
+}
+
+func ParseAccessToken(tokenStr string) (*JWTClaims, error) {
+	return parseToken(tokenStr, signingAlg(), accessKeyFunc, TokenTypeAccess, accessAudience())
 }
 
 func ValidateAndGetUserID(tokenStr string) (string, error) {
@@ -68,28 +205,87 @@ func ValidateAndGetUserID(tokenStr string) (string, error) {
 }
 
 // =====================================================
-// REFRESH TOKEN – TIDAK membawa permissions
+// REFRESH TOKEN – TIDAK membawa role & permissions
 // =====================================================
-func GenerateRefreshToken(user *models.User) (string, error) {
-	claims := JWTClaims{
-		ID:   user.ID,
-		Username: user.Username,
-		Role: user.RoleID,
-		Permissions: nil, // refresh token tidak butuh permission
+// Claims dikembalikan agar jti & expiry bisa dicatat di tabel sessions.
+func GenerateRefreshToken(user *models.User, sessionID string) (string, *JWTClaims, error) {
+	key, err := refreshSecret()
+	if err != nil {
+		return "", nil, err
+	}
+
+	claims := &JWTClaims{
+		ID:        user.ID,
+		Username:  user.Username,
+		SessionID: sessionID,
+		TokenType: TokenTypeRefresh,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour * 7)),
+			ID:        uuid.New().String(),
+			Issuer:    tokenIssuer(),
+			Subject:   user.ID,
+			Audience:  jwt.ClaimStrings{refreshAudience},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(RefreshTokenTTL())),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(os.Getenv("JWT_SECRET")))
+	signed, err := token.SignedString(key)
+	if err != nil {
+		return "", nil, err
+	}
+	return signed, claims, nil
 }
 
 func ParseRefreshToken(tokenStr string) (*JWTClaims, error) {
-	token, err := jwt.ParseWithClaims(tokenStr, &JWTClaims{}, func(t *jwt.Token) (interface{}, error) {
-		return []byte(os.Getenv("JWT_SECRET")), nil
-	})
+	key, err := refreshSecret()
+	if err != nil {
+		return nil, ErrTokenInvalid
+	}
+	keyFunc := func(t *jwt.Token) (interface{}, error) { return key, nil }
+	return parseToken(tokenStr, jwt.SigningMethodHS256.Alg(), keyFunc, TokenTypeRefresh, refreshAudience)
+}
+
+// =====================================================
+// MFA CHALLENGE TOKEN – bukti password benar, belum lolos TOTP
+// =====================================================
+func GenerateMFAToken(user *models.User, ttl time.Duration) (string, error) {
+	key, err := derivedSecret("mfa-token-signing-key")
+	if err != nil {
+		return "", err
+	}
+
+	claims := &JWTClaims{
+		ID:        user.ID,
+		Username:  user.Username,
+		TokenType: TokenTypeMFA,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.New().String(),
+			Issuer:    tokenIssuer(),
+			Subject:   user.ID,
+			Audience:  jwt.ClaimStrings{mfaAudience},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(key)
+}
+
+func ParseMFAToken(tokenStr string) (*JWTClaims, error) {
+	key, err := derivedSecret("mfa-token-signing-key")
+	if err != nil {
+		return nil, ErrTokenInvalid
+	}
+	keyFunc := func(t *jwt.Token) (interface{}, error) { return key, nil }
+	return parseToken(tokenStr, jwt.SigningMethodHS256.Alg(), keyFunc, TokenTypeMFA, mfaAudience)
+}
+
+// parseToken memvalidasi algoritma, signature, expiry, issuer, audience dan typ.
+func parseToken(tokenStr, alg string, keyFunc jwt.Keyfunc, tokenType, audience string) (*JWTClaims, error) {
+	parser := jwt.NewParser(jwt.WithValidMethods([]string{alg}))
+
+	token, err := parser.ParseWithClaims(tokenStr, &JWTClaims{}, keyFunc)
 	if err != nil {
 		return nil, ErrTokenInvalid
 	}
@@ -99,6 +295,11 @@ func ParseRefreshToken(tokenStr string) (*JWTClaims, error) {
 		return nil, ErrTokenInvalid
 	}
 
+	if claims.TokenType != tokenType ||
+		!claims.VerifyAudience(audience, true) ||
+		!claims.VerifyIssuer(tokenIssuer(), true) {
+		return nil, ErrTokenInvalid
+	}
+
 	return claims, nil
 }
-
@@ -0,0 +1,475 @@
+package utils
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// =====================================================
+// ASYMMETRIC SIGNING KEY (RS256 / EdDSA) UNTUK ACCESS TOKEN
+// =====================================================
+// JWT_SIGNING_ALG=HS256 (default) tetap memakai JWT_SECRET.
+// Untuk RS256/EdDSA, private key (PEM) dibaca dari JWT_KEY_DIR dan
+// nama file tanpa ".pem" menjadi "kid". Waktu pembuatan key disimpan di
+// file metadata {kid}.json (bukan ModTime) agar tidak berubah saat folder
+// disalin / di-restore. Semua key di folder langsung
+// dipublikasikan lewat JWKS dan diterima saat verifikasi, tapi key baru baru
+// dipakai signing setelah keyPublishDelay agar instance lain & cache JWKS
+// konsumen sudah mengenal kid-nya.
+// Refresh token tetap HS256 karena hanya diverifikasi oleh service ini.
+
+type signingKey struct {
+	Kid       string
+	Private   crypto.Signer
+	Public    crypto.PublicKey
+	CreatedAt time.Time
+}
+
+type keySet struct {
+	mux    sync.RWMutex
+	alg    string
+	dir    string
+	keys   map[string]*signingKey
+	active *signingKey
+}
+
+var accessKeys = &keySet{alg: "HS256", keys: map[string]*signingKey{}}
+
+// JWKSCacheTTL dipakai untuk header Cache-Control endpoint JWKS (JWT_JWKS_CACHE_TTL, default 5m)
+func JWKSCacheTTL() time.Duration {
+	d, err := time.ParseDuration(os.Getenv("JWT_JWKS_CACHE_TTL"))
+	if err != nil || d <= 0 {
+		return 5 * time.Minute
+	}
+	return d
+}
+
+// keyReloadInterval: seberapa sering folder key dibaca ulang (JWT_KEY_RELOAD_INTERVAL, default 1m),
+// terpisah dari interval rotasi
+func keyReloadInterval() time.Duration {
+	d, err := time.ParseDuration(os.Getenv("JWT_KEY_RELOAD_INTERVAL"))
+	if err != nil || d <= 0 {
+		return time.Minute
+	}
+	return d
+}
+
+// keyPublishDelay: lama key baru hanya dipublikasikan sebelum dipakai signing,
+// cukup untuk satu siklus reload instance lain + satu TTL cache JWKS
+func keyPublishDelay() time.Duration {
+	return keyReloadInterval() + JWKSCacheTTL()
+}
+
+func signingAlg() string {
+	accessKeys.mux.RLock()
+	defer accessKeys.mux.RUnlock()
+	return accessKeys.alg
+}
+
+func isAsymmetric(alg string) bool {
+	return alg == "RS256" || alg == "EdDSA"
+}
+
+func signingMethod(alg string) jwt.SigningMethod {
+	switch alg {
+	case "RS256":
+		return jwt.SigningMethodRS256
+	case "EdDSA":
+		return jwt.SigningMethodEdDSA
+	default:
+		return jwt.SigningMethodHS256
+	}
+}
+
+// InitSigningKeys dipanggil di main.go setelah env di-load.
+func InitSigningKeys() error {
+	alg := os.Getenv("JWT_SIGNING_ALG")
+	if alg == "" {
+		alg = "HS256"
+	}
+
+	switch alg {
+	case "HS256":
+		// shared secret tidak pernah dipublikasikan lewat JWKS
+		accessKeys.mux.Lock()
+		accessKeys.alg = alg
+		accessKeys.keys = map[string]*signingKey{}
+		accessKeys.active = nil
+		accessKeys.mux.Unlock()
+		return nil
+	case "RS256", "EdDSA":
+	default:
+		return fmt.Errorf("unsupported JWT_SIGNING_ALG %q", alg)
+	}
+
+	dir := os.Getenv("JWT_KEY_DIR")
+	if dir == "" {
+		dir = "keys"
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+
+	accessKeys.mux.Lock()
+	accessKeys.alg = alg
+	accessKeys.dir = dir
+	accessKeys.mux.Unlock()
+
+	if err := accessKeys.reload(); err != nil {
+		return err
+	}
+
+	if accessKeys.current() == nil {
+		if os.Getenv("JWT_KEY_AUTOGEN") != "true" {
+			return fmt.Errorf("no %s signing key found in %s", alg, dir)
+		}
+		if err := accessKeys.generate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// StartKeyRotation membaca ulang folder key setiap keyReloadInterval (agar key
+// baru dari instance lain ikut dipublikasikan, lalu dipakai setelah
+// keyPublishDelay) sekaligus menghapus key lama yang token-nya pasti sudah
+// expired, dan jika JWT_KEY_AUTOGEN=true setiap interval membuat key baru.
+func StartKeyRotation(ctx context.Context, interval time.Duration) {
+	if !isAsymmetric(signingAlg()) {
+		return
+	}
+
+	go func() {
+		reload := time.NewTicker(keyReloadInterval())
+		defer reload.Stop()
+		rotate := time.NewTicker(interval)
+		defer rotate.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-rotate.C:
+				if os.Getenv("JWT_KEY_AUTOGEN") == "true" {
+					if err := accessKeys.generate(); err != nil {
+						log.Printf("[WARN] key rotation: %v", err)
+					}
+				}
+			case <-reload.C:
+				if err := accessKeys.reload(); err != nil {
+					log.Printf("[WARN] key reload: %v", err)
+					continue
+				}
+				accessKeys.prune()
+			}
+		}
+	}()
+}
+
+func (ks *keySet) current() *signingKey {
+	ks.mux.RLock()
+	defer ks.mux.RUnlock()
+	return ks.active
+}
+
+func (ks *keySet) lookup(kid string) *signingKey {
+	ks.mux.RLock()
+	defer ks.mux.RUnlock()
+	return ks.keys[kid]
+}
+
+func (ks *keySet) reload() error {
+	ks.mux.RLock()
+	dir, alg := ks.dir, ks.alg
+	ks.mux.RUnlock()
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return err
+	}
+
+	// active = key terbaru yang sudah dipublikasikan cukup lama; jika belum ada
+	// (misal saat pertama kali start) key terbaru langsung dipakai
+	publishedBefore := time.Now().Add(-keyPublishDelay())
+	keys := map[string]*signingKey{}
+	var active, newest *signingKey
+	for _, f := range files {
+		key, err := readPrivateKey(f, alg)
+		if err != nil {
+			log.Printf("[WARN] skip signing key %s: %v", f, err)
+			continue
+		}
+		keys[key.Kid] = key
+		if newest == nil || key.CreatedAt.After(newest.CreatedAt) {
+			newest = key
+		}
+		if !key.CreatedAt.After(publishedBefore) && (active == nil || key.CreatedAt.After(active.CreatedAt)) {
+			active = key
+		}
+	}
+	if active == nil {
+		active = newest
+	}
+
+	ks.mux.Lock()
+	ks.keys = keys
+	ks.active = active
+	ks.mux.Unlock()
+	return nil
+}
+
+func (ks *keySet) generate() error {
+	ks.mux.RLock()
+	dir, alg := ks.dir, ks.alg
+	ks.mux.RUnlock()
+
+	var priv interface{}
+	switch alg {
+	case "RS256":
+		k, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			return err
+		}
+		priv = k
+	case "EdDSA":
+		_, k, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return err
+		}
+		priv = k
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		return err
+	}
+
+	// suffix acak: instance lain yang berbagi folder bisa generate di detik yang sama
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return err
+	}
+	kid := time.Now().UTC().Format("20060102T150405Z") + "-" + hex.EncodeToString(suffix)
+
+	// metadata ditulis lebih dulu agar reload tidak pernah melihat key tanpa created_at
+	if err := writeKeyMeta(dir, kid, keyMeta{CreatedAt: time.Now().UTC()}); err != nil {
+		return err
+	}
+	path := filepath.Join(dir, kid+".pem")
+	data := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return err
+	}
+
+	log.Printf("new %s signing key generated (kid=%s), active after %s", alg, kid, keyPublishDelay())
+	return ks.reload()
+}
+
+// prune menghapus key yang lebih tua dari key aktif setelah key aktif pasti
+// dipakai semua instance (keyPublishDelay + keyReloadInterval) dan token
+// terakhir dari key lama sudah expired. Berlaku juga untuk folder yang
+// dirotasi manual (JWT_KEY_AUTOGEN=false).
+func (ks *keySet) prune() {
+	ks.mux.RLock()
+	active, dir := ks.active, ks.dir
+	var old []string
+	if active != nil && time.Since(active.CreatedAt) > keyPublishDelay()+keyReloadInterval()+AccessTokenTTL() {
+		for kid, key := range ks.keys {
+			if key != active && key.CreatedAt.Before(active.CreatedAt) {
+				old = append(old, kid)
+			}
+		}
+	}
+	ks.mux.RUnlock()
+
+	if len(old) == 0 {
+		return
+	}
+	for _, kid := range old {
+		if err := os.Remove(filepath.Join(dir, kid+".pem")); err != nil {
+			log.Printf("[WARN] remove signing key %s: %v", kid, err)
+			continue
+		}
+		os.Remove(keyMetaPath(dir, kid))
+		log.Printf("signing key %s pruned", kid)
+	}
+	if err := ks.reload(); err != nil {
+		log.Printf("[WARN] key reload: %v", err)
+	}
+}
+
+// keyMeta disimpan di {kid}.json di samping file PEM
+type keyMeta struct {
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func keyMetaPath(dir, kid string) string {
+	return filepath.Join(dir, kid+".json")
+}
+
+func writeKeyMeta(dir, kid string, meta keyMeta) error {
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(keyMetaPath(dir, kid), data, 0o600)
+}
+
+// readKeyMeta membaca metadata key. Key tanpa metadata (ditambahkan manual
+// atau dibuat versi lama) dicatat sekali memakai ModTime file saat itu.
+func readKeyMeta(pemPath, kid string) (keyMeta, error) {
+	var meta keyMeta
+	dir := filepath.Dir(pemPath)
+	data, err := os.ReadFile(keyMetaPath(dir, kid))
+	if err == nil {
+		err = json.Unmarshal(data, &meta)
+		return meta, err
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return meta, err
+	}
+	info, err := os.Stat(pemPath)
+	if err != nil {
+		return meta, err
+	}
+	meta.CreatedAt = info.ModTime().UTC()
+	return meta, writeKeyMeta(dir, kid, meta)
+}
+
+func readPrivateKey(path, alg string) (*signingKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("invalid PEM")
+	}
+
+	var parsed interface{}
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	default:
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	key := &signingKey{Kid: strings.TrimSuffix(filepath.Base(path), ".pem")}
+
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		if alg != "RS256" {
+			return nil, errors.New("RSA key does not match " + alg)
+		}
+		key.Private, key.Public = k, &k.PublicKey
+	case ed25519.PrivateKey:
+		if alg != "EdDSA" {
+			return nil, errors.New("Ed25519 key does not match " + alg)
+		}
+		key.Private, key.Public = k, k.Public()
+	default:
+		return nil, errors.New("unsupported key type")
+	}
+
+	meta, err := readKeyMeta(path, key.Kid)
+	if err != nil {
+		return nil, fmt.Errorf("key metadata: %w", err)
+	}
+	key.CreatedAt = meta.CreatedAt
+	return key, nil
+}
+
+// signAccess dipakai GenerateAccessToken: HS256 dengan JWT_SECRET, atau key aktif + header kid.
+func signAccess(claims jwt.Claims) (string, error) {
+	alg := signingAlg()
+	if !isAsymmetric(alg) {
+		key, err := accessSecret()
+		if err != nil {
+			return "", err
+		}
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(key)
+	}
+
+	key := accessKeys.current()
+	if key == nil {
+		return "", errors.New("no active signing key")
+	}
+	token := jwt.NewWithClaims(signingMethod(alg), claims)
+	token.Header["kid"] = key.Kid
+	return token.SignedString(key.Private)
+}
+
+// accessKeyFunc memilih key verifikasi berdasarkan header kid.
+func accessKeyFunc(t *jwt.Token) (interface{}, error) {
+	if !isAsymmetric(signingAlg()) {
+		return accessSecret()
+	}
+
+	kid, _ := t.Header["kid"].(string)
+	key := accessKeys.lookup(kid)
+	if key == nil {
+		return nil, ErrTokenInvalid
+	}
+	return key.Public, nil
+}
+
+// =====================================================
+// JWKS – public key untuk service lain (RFC 7517)
+// =====================================================
+func JWKS() map[string]interface{} {
+	accessKeys.mux.RLock()
+	defer accessKeys.mux.RUnlock()
+
+	kids := make([]string, 0, len(accessKeys.keys))
+	for kid := range accessKeys.keys {
+		kids = append(kids, kid)
+	}
+	sort.Strings(kids)
+
+	list := []map[string]string{}
+	for _, kid := range kids {
+		key := accessKeys.keys[kid]
+		jwk := map[string]string{
+			"kid": kid,
+			"use": "sig",
+			"alg": accessKeys.alg,
+		}
+
+		switch pub := key.Public.(type) {
+		case *rsa.PublicKey:
+			jwk["kty"] = "RSA"
+			jwk["n"] = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk["e"] = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk["kty"] = "OKP"
+			jwk["crv"] = "Ed25519"
+			jwk["x"] = base64.RawURLEncoding.EncodeToString(pub)
+		}
+		list = append(list, jwk)
+	}
+
+	return map[string]interface{}{"keys": list}
+}
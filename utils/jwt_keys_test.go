@@ -0,0 +1,107 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func setupKeyDir(t *testing.T) string {
+	setupJWTEnv(t)
+	dir := t.TempDir()
+	t.Setenv("JWT_SIGNING_ALG", "EdDSA")
+	t.Setenv("JWT_KEY_DIR", dir)
+	t.Setenv("JWT_KEY_AUTOGEN", "true")
+	t.Setenv("JWT_EXPIRE", "15m")
+	if err := InitSigningKeys(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		os.Setenv("JWT_SIGNING_ALG", "HS256")
+		_ = InitSigningKeys()
+	})
+	return dir
+}
+
+func TestKeyGenerateUniqueKid(t *testing.T) {
+	setupKeyDir(t)
+	// dua key dalam detik yang sama tidak boleh saling menimpa
+	if err := accessKeys.generate(); err != nil {
+		t.Fatal(err)
+	}
+	if err := accessKeys.generate(); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(accessKeys.keys); n != 3 {
+		t.Fatalf("expected 3 keys, got %d", n)
+	}
+}
+
+func TestKeyCreatedAtIgnoresModTime(t *testing.T) {
+	dir := setupKeyDir(t)
+	kid := accessKeys.current().Kid
+	created := accessKeys.current().CreatedAt
+
+	// folder disalin / di-restore: ModTime berubah, created_at tetap
+	future := time.Now().Add(48 * time.Hour)
+	if err := os.Chtimes(filepath.Join(dir, kid+".pem"), future, future); err != nil {
+		t.Fatal(err)
+	}
+	if err := accessKeys.reload(); err != nil {
+		t.Fatal(err)
+	}
+	if got := accessKeys.lookup(kid).CreatedAt; !got.Equal(created) {
+		t.Fatalf("created_at changed from %s to %s", created, got)
+	}
+}
+
+func TestKeyPrune(t *testing.T) {
+	dir := setupKeyDir(t)
+	t.Setenv("JWT_KEY_AUTOGEN", "false")
+	oldKid := accessKeys.current().Kid
+	// key lama sudah lama dipublikasikan
+	if err := writeKeyMeta(dir, oldKid, keyMeta{CreatedAt: time.Now().Add(-time.Hour)}); err != nil {
+		t.Fatal(err)
+	}
+	if err := accessKeys.generate(); err != nil {
+		t.Fatal(err)
+	}
+	newKid := ""
+	for kid := range accessKeys.keys {
+		if kid != oldKid {
+			newKid = kid
+		}
+	}
+
+	// key baru belum lewat publish delay: key lama tetap aktif & tidak dihapus
+	accessKeys.prune()
+	if accessKeys.current().Kid != oldKid || accessKeys.lookup(newKid) == nil {
+		t.Fatal("new key activated or old key pruned too early")
+	}
+
+	// key baru sudah aktif cukup lama: key lama dihapus beserta metadatanya
+	age := keyPublishDelay() + keyReloadInterval() + AccessTokenTTL() + time.Hour
+	if err := writeKeyMeta(dir, oldKid, keyMeta{CreatedAt: time.Now().Add(-2 * age)}); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeKeyMeta(dir, newKid, keyMeta{CreatedAt: time.Now().Add(-age)}); err != nil {
+		t.Fatal(err)
+	}
+	if err := accessKeys.reload(); err != nil {
+		t.Fatal(err)
+	}
+	accessKeys.prune()
+
+	if accessKeys.current().Kid != newKid {
+		t.Fatalf("expected %s active", newKid)
+	}
+	if accessKeys.lookup(oldKid) != nil {
+		t.Fatal("old key still loaded")
+	}
+	for _, name := range []string{oldKid + ".pem", oldKid + ".json"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); !os.IsNotExist(err) {
+			t.Fatalf("%s not removed", name)
+		}
+	}
+}
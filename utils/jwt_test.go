@@ -0,0 +1,165 @@
+package utils
+
+import (
+	"crypto/x509"
+	"testing"
+	"time"
+
+	"achievements-uas/app/models"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+)
+
+func setupJWTEnv(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-access-secret")
+	t.Setenv("JWT_REFRESH_SECRET", "test-refresh-secret")
+	t.Setenv("JWT_ISSUER", "achievements-uas")
+	t.Setenv("JWT_AUDIENCE", "achievements-uas-api")
+	t.Setenv("JWT_SIGNING_ALG", "HS256")
+	if err := InitSigningKeys(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// signHS256 membuat access token HS256 dengan JWT_SECRET dan klaim yang bisa diubah
+func signHS256(t *testing.T, mutate func(c *JWTClaims)) string {
+	claims := &JWTClaims{
+		ID:        "user-1",
+		Username:  "tester",
+		TokenType: TokenTypeAccess,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.New().String(),
+			Issuer:    tokenIssuer(),
+			Subject:   "user-1",
+			Audience:  jwt.ClaimStrings{accessAudience()},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+	if mutate != nil {
+		mutate(claims)
+	}
+	key, err := accessSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func TestTokenTypeSeparation(t *testing.T) {
+	setupJWTEnv(t)
+	user := &models.User{ID: "user-1", Username: "tester", RoleID: "role-1"}
+
+	access, err := GenerateAccessToken(user, "Mahasiswa", nil, 1, "sid-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	refresh, _, err := GenerateRefreshToken(user, "sid-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mfa, err := GenerateMFAToken(user, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		parse func(string) (*JWTClaims, error)
+		ok    bool
+	}{
+		{"access as access", access, ParseAccessToken, true},
+		{"refresh as refresh", refresh, ParseRefreshToken, true},
+		{"mfa as mfa", mfa, ParseMFAToken, true},
+		{"access as refresh", access, ParseRefreshToken, false},
+		{"access as mfa", access, ParseMFAToken, false},
+		{"refresh as access", refresh, ParseAccessToken, false},
+		{"refresh as mfa", refresh, ParseMFAToken, false},
+		{"mfa as access", mfa, ParseAccessToken, false},
+		{"mfa as refresh", mfa, ParseRefreshToken, false},
+		{"wrong aud", signHS256(t, func(c *JWTClaims) { c.Audience = jwt.ClaimStrings{"other-api"} }), ParseAccessToken, false},
+		{"wrong iss", signHS256(t, func(c *JWTClaims) { c.Issuer = "other-issuer" }), ParseAccessToken, false},
+		{"wrong typ", signHS256(t, func(c *JWTClaims) { c.TokenType = TokenTypeRefresh }), ParseAccessToken, false},
+		{"valid hand-made access", signHS256(t, nil), ParseAccessToken, true},
+		{"refresh signed with access key", signHS256(t, func(c *JWTClaims) {
+			c.TokenType = TokenTypeRefresh
+			c.Audience = jwt.ClaimStrings{refreshAudience}
+		}), ParseRefreshToken, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.parse(tt.token)
+			if tt.ok && err != nil {
+				t.Fatalf("expected token to be accepted, got %v", err)
+			}
+			if !tt.ok && err == nil {
+				t.Fatal("expected token to be rejected")
+			}
+		})
+	}
+}
+
+func TestAlgorithmConfusion(t *testing.T) {
+	for _, alg := range []string{"RS256", "EdDSA"} {
+		t.Run(alg, func(t *testing.T) {
+			setupJWTEnv(t)
+			t.Setenv("JWT_SIGNING_ALG", alg)
+			t.Setenv("JWT_KEY_DIR", t.TempDir())
+			t.Setenv("JWT_KEY_AUTOGEN", "true")
+			if err := InitSigningKeys(); err != nil {
+				t.Fatal(err)
+			}
+			defer func() {
+				t.Setenv("JWT_SIGNING_ALG", "HS256")
+				_ = InitSigningKeys()
+			}()
+
+			user := &models.User{ID: "user-1", Username: "tester"}
+			good, err := GenerateAccessToken(user, "Mahasiswa", nil, 1, "sid-1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := ParseAccessToken(good); err != nil {
+				t.Fatalf("%s token rejected: %v", alg, err)
+			}
+
+			key := accessKeys.current()
+			pubDER, err := x509.MarshalPKIXPublicKey(key.Public)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// HS256 dengan JWT_SECRET maupun dengan public key sebagai HMAC secret
+			for name, secret := range map[string][]byte{
+				"jwt secret": []byte("test-access-secret"),
+				"public key": pubDER,
+			} {
+				claims := &JWTClaims{
+					ID:        "user-1",
+					TokenType: TokenTypeAccess,
+					RegisteredClaims: jwt.RegisteredClaims{
+						Issuer:    tokenIssuer(),
+						Audience:  jwt.ClaimStrings{accessAudience()},
+						ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+					},
+				}
+				token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+				token.Header["kid"] = key.Kid
+				forged, err := token.SignedString(secret)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if _, err := ParseAccessToken(forged); err == nil {
+					t.Fatalf("HS256 token signed with %s accepted while %s is expected", name, alg)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package utils
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// =====================================================
+// LDAP / ACTIVE DIRECTORY BIND
+// =====================================================
+// Alur: bind dengan service account -> cari DN user (LDAP_USER_FILTER)
+// -> bind ulang sebagai DN tersebut dengan password user.
+// Atribut direktori dipetakan lewat LDAP_ATTR_* (default untuk OpenLDAP;
+// untuk AD biasanya displayName / mail / department).
+
+type LDAPConfig struct {
+	URL          string
+	StartTLS     bool
+	InsecureTLS  bool
+	BindDN       string
+	BindPassword string
+	BaseDN       string
+	UserFilter   string
+	AttrName     string
+	AttrEmail    string
+	AttrDept     string
+	Timeout      time.Duration
+}
+
+// LDAPEntry adalah atribut yang dipetakan dari direktori
+type LDAPEntry struct {
+	DN         string
+	FullName   string
+	Email      string
+	Department string
+}
+
+var (
+	ErrLDAPInvalidCredentials = errors.New("invalid directory credentials")
+	ErrLDAPDisabled           = errors.New("ldap is not configured")
+)
+
+func envOr(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+func LDAPConfigFromEnv() LDAPConfig {
+	timeout, err := time.ParseDuration(os.Getenv("LDAP_TIMEOUT"))
+	if err != nil {
+		timeout = 5 * time.Second
+	}
+	return LDAPConfig{
+		URL:          os.Getenv("LDAP_URL"),
+		StartTLS:     os.Getenv("LDAP_START_TLS") == "true",
+		InsecureTLS:  os.Getenv("LDAP_INSECURE_SKIP_VERIFY") == "true",
+		BindDN:       os.Getenv("LDAP_BIND_DN"),
+		BindPassword: os.Getenv("LDAP_BIND_PASSWORD"),
+		BaseDN:       os.Getenv("LDAP_BASE_DN"),
+		UserFilter:   envOr("LDAP_USER_FILTER", "(uid=%s)"),
+		AttrName:     envOr("LDAP_ATTR_FULL_NAME", "cn"),
+		AttrEmail:    envOr("LDAP_ATTR_EMAIL", "mail"),
+		AttrDept:     envOr("LDAP_ATTR_DEPARTMENT", "departmentNumber"),
+		Timeout:      timeout,
+	}
+}
+
+func (c LDAPConfig) Enabled() bool {
+	return c.URL != "" && c.BaseDN != ""
+}
+
+func (c LDAPConfig) dial() (*ldap.Conn, error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: c.InsecureTLS}
+	conn, err := ldap.DialURL(c.URL,
+		ldap.DialWithTLSConfig(tlsConfig),
+		ldap.DialWithDialer(&net.Dialer{Timeout: c.Timeout}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	conn.SetTimeout(c.Timeout)
+
+	if c.StartTLS {
+		if err := conn.StartTLS(tlsConfig); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return conn, nil
+}
+
+// LDAPAuthenticate memverifikasi password user di direktori dan
+// mengembalikan atribut yang sudah dipetakan.
+func LDAPAuthenticate(cfg LDAPConfig, username, password string) (*LDAPEntry, error) {
+	if !cfg.Enabled() {
+		return nil, ErrLDAPDisabled
+	}
+	// password kosong = unauthenticated bind yang selalu "berhasil" di banyak server
+	if username == "" || password == "" {
+		return nil, ErrLDAPInvalidCredentials
+	}
+
+	conn, err := cfg.dial()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if cfg.BindDN != "" {
+		if err := conn.Bind(cfg.BindDN, cfg.BindPassword); err != nil {
+			return nil, fmt.Errorf("service bind: %w", err)
+		}
+	}
+
+	search := ldap.NewSearchRequest(
+		cfg.BaseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 2, int(cfg.Timeout.Seconds()), false,
+		fmt.Sprintf(cfg.UserFilter, ldap.EscapeFilter(username)),
+		[]string{"dn", cfg.AttrName, cfg.AttrEmail, cfg.AttrDept},
+		nil,
+	)
+	res, err := conn.Search(search)
+	if err != nil {
+		return nil, err
+	}
+	if len(res.Entries) != 1 {
+		// tidak ada / lebih dari satu entry: perlakukan sebagai kredensial salah
+		return nil, ErrLDAPInvalidCredentials
+	}
+	entry := res.Entries[0]
+
+	if err := conn.Bind(entry.DN, password); err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+			return nil, ErrLDAPInvalidCredentials
+		}
+		return nil, err
+	}
+
+	return &LDAPEntry{
+		DN:         entry.DN,
+		FullName:   entry.GetAttributeValue(cfg.AttrName),
+		Email:      entry.GetAttributeValue(cfg.AttrEmail),
+		Department: entry.GetAttributeValue(cfg.AttrDept),
+	}, nil
+}
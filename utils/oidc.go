@@ -0,0 +1,411 @@
+package utils
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// =====================================================
+// OPENID CONNECT CLIENT (authorization code + PKCE)
+// =====================================================
+// Issuer dibaca dari OIDC_ISSUER; endpoint lain diambil dari
+// {issuer}/.well-known/openid-configuration sehingga untuk development
+// cukup arahkan OIDC_ISSUER ke mock provider lokal (http://localhost:...).
+
+type OIDCConfig struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+}
+
+func OIDCConfigFromEnv() OIDCConfig {
+	scopes := strings.Fields(os.Getenv("OIDC_SCOPES"))
+	if len(scopes) == 0 {
+		scopes = []string{"openid", "email", "profile"}
+	}
+	return OIDCConfig{
+		Issuer:       strings.TrimSuffix(os.Getenv("OIDC_ISSUER"), "/"),
+		ClientID:     os.Getenv("OIDC_CLIENT_ID"),
+		ClientSecret: os.Getenv("OIDC_CLIENT_SECRET"),
+		RedirectURL:  os.Getenv("OIDC_REDIRECT_URL"),
+		Scopes:       scopes,
+	}
+}
+
+func (c OIDCConfig) Enabled() bool {
+	return c.Issuer != "" && c.ClientID != "" && c.RedirectURL != ""
+}
+
+// OIDCIdentity adalah hasil verifikasi id_token
+type OIDCIdentity struct {
+	Subject           string `json:"sub"`
+	Email             string `json:"email"`
+	EmailVerified     bool   `json:"email_verified"`
+	Name              string `json:"name"`
+	PreferredUsername string `json:"preferred_username"`
+	Nonce             string `json:"nonce"`
+	jwt.RegisteredClaims
+}
+
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// oidcJWK hanya field yang dipakai; field lain (x5c, x5t, ...) diabaikan
+type oidcJWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+type OIDCProvider struct {
+	Config OIDCConfig
+	Client *http.Client
+
+	mux       sync.RWMutex
+	discovery *oidcDiscovery
+	keys      map[string]interface{}
+	keysAt    time.Time
+}
+
+var ErrOIDCDisabled = errors.New("oidc is not configured")
+
+func NewOIDCProvider(cfg OIDCConfig) *OIDCProvider {
+	return &OIDCProvider{
+		Config: cfg,
+		Client: &http.Client{Timeout: 10 * time.Second},
+		keys:   map[string]interface{}{},
+	}
+}
+
+func (p *OIDCProvider) getJSON(ctx context.Context, endpoint string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := p.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: status %d", endpoint, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// metadata mengambil discovery document sekali lalu di-cache
+func (p *OIDCProvider) metadata(ctx context.Context) (*oidcDiscovery, error) {
+	if !p.Config.Enabled() {
+		return nil, ErrOIDCDisabled
+	}
+
+	p.mux.RLock()
+	d := p.discovery
+	p.mux.RUnlock()
+	if d != nil {
+		return d, nil
+	}
+
+	var doc oidcDiscovery
+	if err := p.getJSON(ctx, p.Config.Issuer+"/.well-known/openid-configuration", &doc); err != nil {
+		return nil, err
+	}
+	if strings.TrimSuffix(doc.Issuer, "/") != p.Config.Issuer {
+		return nil, fmt.Errorf("issuer mismatch: %s", doc.Issuer)
+	}
+	if doc.AuthorizationEndpoint == "" || doc.TokenEndpoint == "" || doc.JWKSURI == "" {
+		return nil, errors.New("incomplete discovery document")
+	}
+
+	p.mux.Lock()
+	p.discovery = &doc
+	p.mux.Unlock()
+	return &doc, nil
+}
+
+// AuthCodeURL membuat URL redirect ke IdP (PKCE S256)
+func (p *OIDCProvider) AuthCodeURL(ctx context.Context, state, nonce, verifier string) (string, error) {
+	d, err := p.metadata(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	challenge := sha256.Sum256([]byte(verifier))
+	q := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.Config.ClientID},
+		"redirect_uri":          {p.Config.RedirectURL},
+		"scope":                 {strings.Join(p.Config.Scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+
+	sep := "?"
+	if strings.Contains(d.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return d.AuthorizationEndpoint + sep + q.Encode(), nil
+}
+
+// Exchange menukar authorization code dan memverifikasi id_token
+// (signature via JWKS, iss, aud, exp, nonce).
+func (p *OIDCProvider) Exchange(ctx context.Context, code, verifier, nonce string) (*OIDCIdentity, error) {
+	d, err := p.metadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.Config.RedirectURL},
+		"client_id":     {p.Config.ClientID},
+		"code_verifier": {verifier},
+	}
+	if p.Config.ClientSecret != "" {
+		form.Set("client_secret", p.Config.ClientSecret)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var tok struct {
+		IDToken string `json:"id_token"`
+		Error   string `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tok); err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK || tok.IDToken == "" {
+		return nil, fmt.Errorf("token endpoint: status %d %s", resp.StatusCode, tok.Error)
+	}
+
+	return p.verifyIDToken(ctx, tok.IDToken, nonce)
+}
+
+func (p *OIDCProvider) verifyIDToken(ctx context.Context, raw, nonce string) (*OIDCIdentity, error) {
+	parser := jwt.NewParser(jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "ES256", "ES384", "EdDSA"}))
+
+	token, err := parser.ParseWithClaims(raw, &OIDCIdentity{}, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		return p.publicKey(ctx, kid)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	id, ok := token.Claims.(*OIDCIdentity)
+	if !ok || !token.Valid {
+		return nil, ErrTokenInvalid
+	}
+	if !id.VerifyIssuer(p.Config.Issuer, true) || !id.VerifyAudience(p.Config.ClientID, true) {
+		return nil, errors.New("id_token issuer/audience mismatch")
+	}
+	if id.Nonce == "" || id.Nonce != nonce {
+		return nil, errors.New("id_token nonce mismatch")
+	}
+	if id.Subject == "" {
+		return nil, errors.New("id_token without subject")
+	}
+	return id, nil
+}
+
+// publicKey mencari key di cache JWKS; kid tak dikenal memicu fetch ulang
+// (maksimal sekali per menit) untuk mendukung rotasi key di IdP.
+func (p *OIDCProvider) publicKey(ctx context.Context, kid string) (interface{}, error) {
+	p.mux.RLock()
+	key, ok := p.keys[kid]
+	fresh := time.Since(p.keysAt) < time.Minute
+	p.mux.RUnlock()
+	if ok {
+		return key, nil
+	}
+	if fresh {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+
+	d, err := p.metadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var set struct {
+		Keys []oidcJWK `json:"keys"`
+	}
+	if err := p.getJSON(ctx, d.JWKSURI, &set); err != nil {
+		return nil, err
+	}
+
+	keys := map[string]interface{}{}
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		if pub, err := parseJWK(jwk); err == nil {
+			keys[jwk.Kid] = pub
+		}
+	}
+
+	p.mux.Lock()
+	p.keys = keys
+	p.keysAt = time.Now()
+	p.mux.Unlock()
+
+	if key, ok := keys[kid]; ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown key id %q", kid)
+}
+
+func parseJWK(jwk oidcJWK) (interface{}, error) {
+	b64 := base64.RawURLEncoding.DecodeString
+
+	switch jwk.Kty {
+	case "RSA":
+		n, err := b64(jwk.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := b64(jwk.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+
+	case "EC":
+		var curve elliptic.Curve
+		switch jwk.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		default:
+			return nil, errors.New("unsupported curve")
+		}
+		x, err := b64(jwk.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := b64(jwk.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+
+	case "OKP":
+		x, err := b64(jwk.X)
+		if err != nil || jwk.Crv != "Ed25519" || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 key")
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, errors.New("unsupported key type")
+}
+
+// =====================================================
+// STATE COOKIE – menyimpan state, nonce & PKCE verifier
+// =====================================================
+// Disimpan sebagai JWT HS256 di cookie HttpOnly sehingga callback bisa
+// diproses instance mana pun tanpa penyimpanan server-side.
+
+type OIDCState struct {
+	State    string `json:"state"`
+	Nonce    string `json:"nonce"`
+	Verifier string `json:"verifier"`
+	jwt.RegisteredClaims
+}
+
+func randomURLString(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// NewOIDCState membuat state baru beserta versi tertandatangan untuk cookie
+func NewOIDCState(ttl time.Duration) (*OIDCState, string, error) {
+	st := &OIDCState{}
+	var err error
+	if st.State, err = randomURLString(24); err != nil {
+		return nil, "", err
+	}
+	if st.Nonce, err = randomURLString(24); err != nil {
+		return nil, "", err
+	}
+	if st.Verifier, err = randomURLString(48); err != nil {
+		return nil, "", err
+	}
+	st.ExpiresAt = jwt.NewNumericDate(time.Now().Add(ttl))
+
+	key, err := derivedSecret("oidc-state-signing-key")
+	if err != nil {
+		return nil, "", err
+	}
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, st).SignedString(key)
+	if err != nil {
+		return nil, "", err
+	}
+	return st, signed, nil
+}
+
+func ParseOIDCState(raw string) (*OIDCState, error) {
+	key, err := derivedSecret("oidc-state-signing-key")
+	if err != nil {
+		return nil, ErrTokenInvalid
+	}
+
+	parser := jwt.NewParser(jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	token, err := parser.ParseWithClaims(raw, &OIDCState{}, func(t *jwt.Token) (interface{}, error) { return key, nil })
+	if err != nil {
+		return nil, ErrTokenInvalid
+	}
+	st, ok := token.Claims.(*OIDCState)
+	if !ok || !token.Valid {
+		return nil, ErrTokenInvalid
+	}
+	return st, nil
+}
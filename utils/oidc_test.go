@@ -0,0 +1,165 @@
+package utils
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// mockOIDC adalah IdP lokal minimal: discovery, JWKS dan token endpoint
+type mockOIDC struct {
+	srv     *httptest.Server
+	key     *rsa.PrivateKey
+	kid     string
+	idToken string
+	// form terakhir yang diterima token endpoint
+	form url.Values
+}
+
+func newMockOIDC(t *testing.T) *mockOIDC {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &mockOIDC{key: key, kid: "mock-key-1"}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 m.srv.URL,
+			"authorization_endpoint": m.srv.URL + "/authorize",
+			"token_endpoint":         m.srv.URL + "/token",
+			"jwks_uri":               m.srv.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		pub := &m.key.PublicKey
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]interface{}{{
+				"kty": "RSA",
+				"kid": m.kid,
+				"use": "sig",
+				"alg": "RS256",
+				"n":   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+				// seperti Keycloak / Azure AD: x5c berupa array
+				"x5c": []string{"MIIBmock"},
+			}},
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		m.form = r.PostForm
+		if r.PostForm.Get("code") != "good-code" {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"id_token": m.idToken, "token_type": "Bearer"})
+	})
+	m.srv = httptest.NewServer(mux)
+	t.Cleanup(m.srv.Close)
+	return m
+}
+
+func (m *mockOIDC) sign(t *testing.T, claims *OIDCIdentity) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = m.kid
+	signed, err := token.SignedString(m.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func TestOIDCExchange(t *testing.T) {
+	m := newMockOIDC(t)
+	p := NewOIDCProvider(OIDCConfig{
+		Issuer:      m.srv.URL,
+		ClientID:    "achievements-uas",
+		RedirectURL: "http://localhost:3000/api/v1/auth/oidc/callback",
+		Scopes:      []string{"openid", "email"},
+	})
+	ctx := context.Background()
+
+	authURL, err := p.AuthCodeURL(ctx, "state-1", "nonce-1", "verifier-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	challenge := sha256.Sum256([]byte("verifier-1"))
+	if got := u.Query().Get("code_challenge"); got != base64.RawURLEncoding.EncodeToString(challenge[:]) {
+		t.Fatalf("unexpected code_challenge %q", got)
+	}
+	if u.Query().Get("nonce") != "nonce-1" || u.Query().Get("state") != "state-1" {
+		t.Fatalf("state/nonce missing from %s", authURL)
+	}
+
+	valid := func(c *OIDCIdentity) {}
+	tests := []struct {
+		name   string
+		code   string
+		nonce  string
+		mutate func(c *OIDCIdentity)
+		ok     bool
+	}{
+		{"valid", "good-code", "nonce-1", valid, true},
+		{"wrong nonce", "good-code", "nonce-2", valid, false},
+		{"missing nonce", "good-code", "nonce-1", func(c *OIDCIdentity) { c.Nonce = "" }, false},
+		{"wrong aud", "good-code", "nonce-1", func(c *OIDCIdentity) { c.Audience = jwt.ClaimStrings{"other-client"} }, false},
+		{"wrong iss", "good-code", "nonce-1", func(c *OIDCIdentity) { c.Issuer = "https://evil.example" }, false},
+		{"expired", "good-code", "nonce-1", func(c *OIDCIdentity) { c.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute)) }, false},
+		{"missing subject", "good-code", "nonce-1", func(c *OIDCIdentity) { c.Subject = "" }, false},
+		{"rejected code", "bad-code", "nonce-1", valid, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := &OIDCIdentity{
+				Subject:       "idp-user-1",
+				Email:         "mhs@kampus.ac.id",
+				EmailVerified: true,
+				Nonce:         "nonce-1",
+				RegisteredClaims: jwt.RegisteredClaims{
+					Issuer:    m.srv.URL,
+					Audience:  jwt.ClaimStrings{"achievements-uas"},
+					ExpiresAt: jwt.NewNumericDate(time.Now().Add(5 * time.Minute)),
+					IssuedAt:  jwt.NewNumericDate(time.Now()),
+				},
+			}
+			tt.mutate(claims)
+			m.idToken = m.sign(t, claims)
+
+			id, err := p.Exchange(ctx, tt.code, "verifier-1", tt.nonce)
+			if !tt.ok {
+				if err == nil {
+					t.Fatal("expected exchange to fail")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("exchange failed: %v", err)
+			}
+			if id.Subject != "idp-user-1" || id.Email != "mhs@kampus.ac.id" {
+				t.Fatalf("unexpected identity %+v", id)
+			}
+			if m.form.Get("code_verifier") != "verifier-1" || m.form.Get("grant_type") != "authorization_code" {
+				t.Fatalf("unexpected token request %v", m.form)
+			}
+		})
+	}
+}
@@ -0,0 +1,183 @@
+package utils
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// ===========================
+// PASSWORD POLICY
+// ===========================
+
+type PasswordPolicy struct {
+	MinLength     int
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+	// HistorySize = jumlah hash terakhir yang tidak boleh dipakai ulang
+	HistorySize int
+}
+
+// PasswordPolicyError berisi daftar aturan yang dilanggar
+type PasswordPolicyError struct {
+	Violations []string
+}
+
+func (e *PasswordPolicyError) Error() string {
+	return "password does not meet policy: " + strings.Join(e.Violations, "; ")
+}
+
+var (
+	policy = PasswordPolicy{
+		MinLength:    8,
+		RequireUpper: true,
+		RequireLower: true,
+		RequireDigit: true,
+		HistorySize:  5,
+	}
+	policyMux sync.RWMutex
+
+	// daftar bawaan password paling umum, ditambah isi PASSWORD_BLOCKLIST_FILE
+	breachedPasswords = map[string]struct{}{}
+)
+
+var defaultBreached = []string{
+	"password", "password1", "password123", "passw0rd", "p@ssw0rd", "12345678",
+	"123456789", "1234567890", "qwerty123", "qwertyuiop", "iloveyou", "admin123",
+	"welcome1", "letmein1", "abc12345", "11111111", "00000000", "asdfghjkl",
+	"mahasiswa", "rahasia123", "indonesia", "bismillah",
+}
+
+func envBool(key string, def bool) bool {
+	v, err := strconv.ParseBool(os.Getenv(key))
+	if err != nil {
+		return def
+	}
+	return v
+}
+
+func envInt(key string, def int) int {
+	v, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return def
+	}
+	return v
+}
+
+// InitPasswordPolicy membaca konfigurasi dari env dan memuat daftar password bocor.
+func InitPasswordPolicy() error {
+	p := PasswordPolicy{
+		MinLength:     envInt("PASSWORD_MIN_LENGTH", 8),
+		RequireUpper:  envBool("PASSWORD_REQUIRE_UPPER", true),
+		RequireLower:  envBool("PASSWORD_REQUIRE_LOWER", true),
+		RequireDigit:  envBool("PASSWORD_REQUIRE_DIGIT", true),
+		RequireSymbol: envBool("PASSWORD_REQUIRE_SYMBOL", false),
+		HistorySize:   envInt("PASSWORD_HISTORY_SIZE", 5),
+	}
+
+	list := map[string]struct{}{}
+	for _, pw := range defaultBreached {
+		list[pw] = struct{}{}
+	}
+
+	if path := os.Getenv("PASSWORD_BLOCKLIST_FILE"); path != "" {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			line := strings.ToLower(strings.TrimSpace(scanner.Text()))
+			if line != "" && !strings.HasPrefix(line, "#") {
+				list[line] = struct{}{}
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return err
+		}
+	}
+
+	policyMux.Lock()
+	policy = p
+	breachedPasswords = list
+	policyMux.Unlock()
+	return nil
+}
+
+func CurrentPasswordPolicy() PasswordPolicy {
+	policyMux.RLock()
+	defer policyMux.RUnlock()
+	return policy
+}
+
+// ValidatePassword mengecek aturan policy + daftar password bocor.
+// personal berisi data user (username, email, NIM, dll) yang tidak boleh
+// terkandung di dalam password.
+func ValidatePassword(password string, personal ...string) error {
+	policyMux.RLock()
+	p := policy
+	_, breached := breachedPasswords[strings.ToLower(password)]
+	policyMux.RUnlock()
+
+	var violations []string
+
+	if len([]rune(password)) < p.MinLength {
+		violations = append(violations, fmt.Sprintf("must be at least %d characters", p.MinLength))
+	}
+
+	var upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			symbol = true
+		}
+	}
+	if p.RequireUpper && !upper {
+		violations = append(violations, "must contain an uppercase letter")
+	}
+	if p.RequireLower && !lower {
+		violations = append(violations, "must contain a lowercase letter")
+	}
+	if p.RequireDigit && !digit {
+		violations = append(violations, "must contain a digit")
+	}
+	if p.RequireSymbol && !symbol {
+		violations = append(violations, "must contain a symbol")
+	}
+
+	lowerPw := strings.ToLower(password)
+	for _, v := range personal {
+		v = strings.ToLower(strings.TrimSpace(v))
+		// bagian lokal email saja, "budi@kampus.ac.id" → "budi"
+		if i := strings.Index(v, "@"); i > 0 {
+			v = v[:i]
+		}
+		if len(v) >= 3 && strings.Contains(lowerPw, v) {
+			violations = append(violations, "must not contain your username, email, or ID number")
+			break
+		}
+	}
+
+	if breached {
+		violations = append(violations, "is too common or has appeared in a data breach")
+	}
+
+	if len(violations) > 0 {
+		return &PasswordPolicyError{Violations: violations}
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package utils
+
+import (
+	"errors"
+	"os"
+	"sync"
+	"time"
+)
+
+// =====================================================
+// LIVE PERMISSION (role -> permissions) DENGAN CACHE
+// =====================================================
+// Permission tidak lagi dipercaya dari isi access token. AuthRequired
+// mengambil permission role terbaru dari cache ini, dan membandingkan
+// versinya dengan klaim "pv" untuk mendeteksi token stale.
+//
+// Instance yang mengubah role langsung meng-invalidate cache-nya sendiri;
+// instance lain ikut terbarui setelah PERMISSION_CACHE_TTL (default 30s).
+
+// PermissionSource diimplementasikan oleh RolePermissionRepository.
+type PermissionSource interface {
+	PermissionsWithVersion(roleID string) ([]string, int64, error)
+}
+
+type rolePermissionEntry struct {
+	Permissions []string
+	Version     int64
+	LoadedAt    time.Time
+}
+
+var (
+	permissionSource PermissionSource
+	permissionCache  = map[string]rolePermissionEntry{}
+	permissionMux    sync.RWMutex
+)
+
+var ErrNoPermissionSource = errors.New("permission source is not configured")
+
+// SetPermissionSource dipanggil sekali di main.go.
+func SetPermissionSource(src PermissionSource) {
+	permissionMux.Lock()
+	defer permissionMux.Unlock()
+	permissionSource = src
+	permissionCache = map[string]rolePermissionEntry{}
+}
+
+func permissionCacheTTL() time.Duration {
+	if d, err := time.ParseDuration(os.Getenv("PERMISSION_CACHE_TTL")); err == nil && d >= 0 {
+		return d
+	}
+	return 30 * time.Second
+}
+
+// RolePermissions mengembalikan permission & versi terbaru sebuah role.
+func RolePermissions(roleID string) ([]string, int64, error) {
+	permissionMux.RLock()
+	src := permissionSource
+	entry, ok := permissionCache[roleID]
+	permissionMux.RUnlock()
+
+	if ok && time.Since(entry.LoadedAt) < permissionCacheTTL() {
+		return entry.Permissions, entry.Version, nil
+	}
+	if src == nil {
+		return nil, 0, ErrNoPermissionSource
+	}
+
+	perms, version, err := src.PermissionsWithVersion(roleID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	permissionMux.Lock()
+	permissionCache[roleID] = rolePermissionEntry{Permissions: perms, Version: version, LoadedAt: time.Now()}
+	permissionMux.Unlock()
+	return perms, version, nil
+}
+
+// InvalidateRolePermissions dipanggil setelah role / mapping permission berubah.
+func InvalidateRolePermissions(roleID string) {
+	permissionMux.Lock()
+	defer permissionMux.Unlock()
+	delete(permissionCache, roleID)
+}
+
+// Kebijakan untuk token yang klaim "pv"-nya lebih lama dari versi role:
+// upgrade (default) = permission terbaru langsung dipakai,
+// reject = token ditolak dan client wajib /auth/refresh.
+const (
+	StalePermissionUpgrade = "upgrade"
+	StalePermissionReject  = "reject"
+)
+
+func StalePermissionPolicy() string {
+	if os.Getenv("PERMISSION_STALE_POLICY") == StalePermissionReject {
+		return StalePermissionReject
+	}
+	return StalePermissionUpgrade
+}
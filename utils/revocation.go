@@ -0,0 +1,136 @@
+package utils
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// RevocationStore menyimpan jti token yang sudah di-revoke (logout).
+// Implementasi: memory (default, single instance), Postgres, dan Redis.
+type RevocationStore interface {
+	Revoke(ctx context.Context, jti string, expiresAt time.Time) error
+	IsRevoked(ctx context.Context, jti string) (bool, error)
+	DeleteExpired(ctx context.Context) (int64, error)
+}
+
+var (
+	revocationStore RevocationStore = NewMemoryRevocationStore()
+	revocationMux   sync.RWMutex
+)
+
+// SetRevocationStore dipanggil sekali di main.go untuk memilih backend.
+func SetRevocationStore(store RevocationStore) {
+	revocationMux.Lock()
+	defer revocationMux.Unlock()
+	revocationStore = store
+}
+
+func currentRevocationStore() RevocationStore {
+	revocationMux.RLock()
+	defer revocationMux.RUnlock()
+	return revocationStore
+}
+
+// RevokeToken menandai jti sebagai tidak berlaku sampai waktu expired token.
+func RevokeToken(jti string, expiresAt time.Time) error {
+	if jti == "" {
+		return ErrTokenInvalid
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	return currentRevocationStore().Revoke(ctx, jti, expiresAt)
+}
+
+// IsTokenRevoked mengembalikan error jika backend tidak bisa dihubungi,
+// pemanggil sebaiknya menolak request (fail closed).
+func IsTokenRevoked(jti string) (bool, error) {
+	if jti == "" {
+		return false, nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	return currentRevocationStore().IsRevoked(ctx, jti)
+}
+
+// RevokeSession menandai seluruh access token milik satu session (klaim sid) tidak berlaku.
+func RevokeSession(sessionID string, expiresAt time.Time) error {
+	return RevokeToken("session:"+sessionID, expiresAt)
+}
+
+func IsSessionRevoked(sessionID string) (bool, error) {
+	if sessionID == "" {
+		return false, nil
+	}
+	return IsTokenRevoked("session:" + sessionID)
+}
+
+// StartRevocationSweeper membersihkan entry yang sudah expired secara berkala.
+func StartRevocationSweeper(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				n, err := currentRevocationStore().DeleteExpired(ctx)
+				if err != nil {
+					log.Printf("[WARN] revocation sweeper: %v", err)
+					continue
+				}
+				if n > 0 {
+					log.Printf("revocation sweeper: %d expired entries removed", n)
+				}
+			}
+		}
+	}()
+}
+
+// =====================================================
+// MEMORY STORE – hanya untuk development / single instance
+// =====================================================
+type MemoryRevocationStore struct {
+	mux  sync.RWMutex
+	data map[string]time.Time
+}
+
+func NewMemoryRevocationStore() *MemoryRevocationStore {
+	return &MemoryRevocationStore{data: make(map[string]time.Time)}
+}
+
+func (s *MemoryRevocationStore) Revoke(ctx context.Context, jti string, expiresAt time.Time) error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.data[jti] = expiresAt
+	return nil
+}
+
+func (s *MemoryRevocationStore) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+
+	exp, ok := s.data[jti]
+	if !ok {
+		return false, nil
+	}
+	return time.Now().Before(exp), nil
+}
+
+func (s *MemoryRevocationStore) DeleteExpired(ctx context.Context) (int64, error) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	var n int64
+	now := time.Now()
+	for jti, exp := range s.data {
+		if now.After(exp) {
+			delete(s.data, jti)
+			n++
+		}
+	}
+	return n, nil
+}
@@ -0,0 +1,51 @@
+package utils
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisRevocationStore memakai TTL bawaan Redis, jadi tidak perlu sweeper.
+// Kompatibel dengan server yang mengikuti protokol Redis (Valkey, KeyDB, dll).
+type RedisRevocationStore struct {
+	client *redis.Client
+	prefix string
+}
+
+func NewRedisRevocationStore(url string) (*RedisRevocationStore, error) {
+	opt, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, err
+	}
+
+	client := redis.NewClient(opt)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		return nil, err
+	}
+
+	return &RedisRevocationStore{client: client, prefix: "revoked:"}, nil
+}
+
+func (s *RedisRevocationStore) Revoke(ctx context.Context, jti string, expiresAt time.Time) error {
+	ttl := time.Until(expiresAt)
+	if ttl <= 0 {
+		return nil
+	}
+	return s.client.Set(ctx, s.prefix+jti, 1, ttl).Err()
+}
+
+func (s *RedisRevocationStore) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	n, err := s.client.Exists(ctx, s.prefix+jti).Result()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+func (s *RedisRevocationStore) DeleteExpired(ctx context.Context) (int64, error) {
+	return 0, nil
+}